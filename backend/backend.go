@@ -0,0 +1,62 @@
+//
+// Copyright © 2020-present Sonatype Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+// Package backend has the evaluators that sha1s can be audited with, Nexus IQ Server being one of them, behind one
+// interface so every command can use any of them
+package backend
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/sonatype-nexus-community/go-sona-types/cyclonedx"
+	"github.com/sonatype-nexus-community/hashbrowns/logger"
+	"github.com/sonatype-nexus-community/hashbrowns/report"
+	"github.com/sonatype-nexus-community/hashbrowns/types"
+)
+
+// The names of the backends, as passed to --backend
+const (
+	IQ    = "iq"
+	Local = "local"
+	Mock  = "mock"
+)
+
+var log = logger.Logger()
+
+// Auditor audits sha1s against an application and stage, and normalizes whatever it finds into a report. An error is
+// only returned if the audit could not be done at all, the backend reporting an error is an OutcomeError report.
+type Auditor interface {
+	Audit(sha1s []cyclonedx.Sha1SBOM, application string, stage string) (report.Report, error)
+}
+
+// Names returns the names of every backend
+func Names() []string {
+	return []string{IQ, Local, Mock}
+}
+
+// New returns the backend called name, set up from config
+func New(name string, config *types.Config) (Auditor, error) {
+	switch name {
+	case IQ:
+		return newIQ(config), nil
+	case Local:
+		return newLocal(config.DenyLists, config.AllowLists)
+	case Mock:
+		return newMock(config.MockOutcome)
+	}
+	return nil, fmt.Errorf("Unknown backend %q, supported backends are: %s", name, strings.Join(Names(), ", "))
+}
@@ -0,0 +1,248 @@
+//
+// Copyright © 2020-present Sonatype Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+package backend
+
+import (
+	"testing"
+
+	"github.com/jarcoal/httpmock"
+	"github.com/sonatype-nexus-community/go-sona-types/cyclonedx"
+	"github.com/sonatype-nexus-community/hashbrowns/report"
+	"github.com/sonatype-nexus-community/hashbrowns/types"
+	"github.com/stretchr/testify/assert"
+)
+
+var entries = []cyclonedx.Sha1SBOM{
+	{Sha1: "9987ca4f73d5ea0e534dfbf19238552df4de507e", Location: "lib/foo.jar"},
+	{Sha1: "aaf4c61ddcc5e8a2dabede0f3b482cd9aea9434d", Location: "lib/hello.so"},
+}
+
+const (
+	applicationsResponse = `{"applications": [{"id": "4bb67dcfc86344e3a483832f8c496419", "publicId": "testapp"}]}`
+	statusURL            = "api/v2/scan/applications/4bb67dcfc86344e3a483832f8c496419/status/9cee2b6366fc4d328edc318eae46b2cb"
+	pollingResult        = `{
+		"policyAction": "Failure",
+		"reportHtmlUrl": "http://sillyplace.com:8090/ui/links/application/testapp/report/95c4c14e",
+		"reportDataUrl": "api/v2/applications/testapp/reports/95c4c14e/raw",
+		"isError": false
+	}`
+	policyResult = `{
+		"components": [
+			{
+				"hash": "9987ca4f73d5ea0e534d",
+				"displayName": "foo.jar",
+				"pathnames": ["lib/foo.jar"],
+				"violations": [
+					{"policyName": "Security-Critical", "policyThreatLevel": 10, "waived": false},
+					{"policyName": "License-Banned", "policyThreatLevel": 5, "waived": true}
+				]
+			},
+			{
+				"hash": "aaf4c61ddcc5e8a2dabe",
+				"displayName": "hello.so",
+				"pathnames": ["lib/hello.so"],
+				"violations": [
+					{"policyName": "Architecture-Quality", "policyThreatLevel": 3, "waived": false}
+				]
+			}
+		]
+	}`
+)
+
+// registerIQ has every Nexus IQ Server endpoint an audit uses respond, with pollingResponder for the status URL
+func registerIQ(pollingResponder httpmock.Responder) {
+	httpmock.RegisterResponder("GET", "http://sillyplace.com:8090/api/v2/applications?publicId=testapp",
+		httpmock.NewStringResponder(200, applicationsResponse))
+	httpmock.RegisterResponder("POST", "http://sillyplace.com:8090/api/v2/scan/applications/4bb67dcfc86344e3a483832f8c496419/sources/nancy?stageId=build",
+		httpmock.NewStringResponder(202, `{"statusUrl": "`+statusURL+`"}`))
+	httpmock.RegisterResponder("GET", "http://sillyplace.com:8090/"+statusURL, pollingResponder)
+	httpmock.RegisterResponder("GET", "http://sillyplace.com:8090/api/v2/applications/testapp/reports/95c4c14e/policy",
+		httpmock.NewStringResponder(200, policyResult))
+}
+
+func TestNewUnknown(t *testing.T) {
+	auditor, err := New("nope", &types.Config{})
+
+	assert.Nil(t, auditor)
+	assert.Equal(t, "Unknown backend \"nope\", supported backends are: iq, local, mock", err.Error())
+}
+
+func TestMock(t *testing.T) {
+	auditor, err := New(Mock, &types.Config{MockOutcome: report.OutcomeFailure})
+	assert.NoError(t, err)
+
+	r, err := auditor.Audit(entries, "testapp", "develop")
+
+	assert.NoError(t, err)
+	assert.Equal(t, report.OutcomeFailure, r.Outcome)
+	assert.Equal(t, 2, len(r.Violations))
+	assert.Equal(t, [][]cyclonedx.Sha1SBOM{entries}, auditor.(*MockAuditor).Audited())
+}
+
+func TestMockUnknownOutcome(t *testing.T) {
+	_, err := New(Mock, &types.Config{MockOutcome: "maybe"})
+
+	assert.Equal(t, "Unknown mock outcome \"maybe\", supported outcomes are: pass, failure, error", err.Error())
+}
+
+func TestLocal(t *testing.T) {
+	auditor, err := New(Local, &types.Config{DenyLists: []string{"testdata/deny.json"}})
+	assert.NoError(t, err)
+
+	r, err := auditor.Audit(entries, "", "")
+
+	assert.NoError(t, err)
+	assert.Equal(t, report.Report{
+		Backend:      Local,
+		Outcome:      report.OutcomeFailure,
+		PolicyAction: "Failure",
+		Violations: []report.Violation{
+			{Sha1: "9987ca4f73d5ea0e534dfbf19238552df4de507e", Location: "lib/foo.jar", Reason: "Backdoored build of foo", Severity: "critical"},
+		},
+	}, r)
+}
+
+func TestLocalNeedsDenyList(t *testing.T) {
+	_, err := New(Local, &types.Config{})
+
+	assert.Equal(t, "The local backend needs at least one deny list, see usage for more information", err.Error())
+}
+
+func TestIQ(t *testing.T) {
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+	registerIQ(httpmock.NewStringResponder(200, pollingResult))
+
+	auditor, err := New(IQ, &types.Config{Server: "http://sillyplace.com:8090", User: "hashbrowns", Token: "secret", MaxRetries: 1})
+	assert.NoError(t, err)
+
+	r, err := auditor.Audit(entries, "testapp", "build")
+
+	assert.NoError(t, err)
+	assert.Equal(t, report.Report{
+		Backend:      IQ,
+		Outcome:      report.OutcomeFailure,
+		PolicyAction: "Failure",
+		ReportURL:    "http://sillyplace.com:8090/ui/links/application/testapp/report/95c4c14e",
+		Violations: []report.Violation{
+			{Sha1: "9987ca4f73d5ea0e534d", Location: "lib/foo.jar", Reason: "Security-Critical", Severity: "critical", ThreatLevel: 10},
+			{Sha1: "aaf4c61ddcc5e8a2dabe", Location: "lib/hello.so", Reason: "Architecture-Quality", Severity: "medium", ThreatLevel: 3},
+		},
+		ViolationsUnconfirmed: true,
+	}, r)
+}
+
+func TestIQPolicyReportIsBestEffort(t *testing.T) {
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+
+	httpmock.RegisterResponder("GET", "http://sillyplace.com:8090/api/v2/applications?publicId=testapp",
+		httpmock.NewStringResponder(200, applicationsResponse))
+	httpmock.RegisterResponder("POST", "http://sillyplace.com:8090/api/v2/scan/applications/4bb67dcfc86344e3a483832f8c496419/sources/nancy?stageId=build",
+		httpmock.NewStringResponder(202, `{"statusUrl": "`+statusURL+`"}`))
+	httpmock.RegisterResponder("GET", "http://sillyplace.com:8090/"+statusURL,
+		httpmock.NewStringResponder(200, pollingResult))
+	httpmock.RegisterResponder("GET", "http://sillyplace.com:8090/api/v2/applications/testapp/reports/95c4c14e/policy",
+		httpmock.NewStringResponder(403, ""))
+
+	auditor, err := New(IQ, &types.Config{Server: "http://sillyplace.com:8090", User: "hashbrowns", Token: "secret", MaxRetries: 1})
+	assert.NoError(t, err)
+
+	r, err := auditor.Audit(entries, "testapp", "build")
+
+	assert.NoError(t, err)
+	assert.Equal(t, report.OutcomeFailure, r.Outcome)
+	assert.Nil(t, r.Violations)
+	assert.False(t, r.ViolationsUnconfirmed)
+}
+
+func TestIQNoPolicyAction(t *testing.T) {
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+	registerIQ(httpmock.NewStringResponder(200, `{"reportHtmlUrl": "http://sillyplace.com:8090/ui/links/application/testapp/report/95c4c14e", "isError": false}`))
+
+	auditor, err := New(IQ, &types.Config{Server: "http://sillyplace.com:8090", User: "hashbrowns", Token: "secret", MaxRetries: 1})
+	assert.NoError(t, err)
+
+	r, err := auditor.Audit(entries, "testapp", "build")
+
+	assert.NoError(t, err)
+	assert.Equal(t, report.OutcomeError, r.Outcome)
+	assert.Equal(t, "Nexus IQ Server did not return a policy action", r.Error)
+}
+
+func TestIQPollingTimesOut(t *testing.T) {
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+	registerIQ(httpmock.NewStringResponder(404, ""))
+
+	auditor, err := New(IQ, &types.Config{Server: "http://sillyplace.com:8090", User: "hashbrowns", Token: "secret", MaxRetries: 0})
+	assert.NoError(t, err)
+
+	_, err = auditor.Audit(entries, "testapp", "build")
+
+	assert.EqualError(t, err, "Nexus IQ Server did not finish evaluating policy after 0 tries, try again or raise --max-retries")
+}
+
+func TestIQPollingUnauthorized(t *testing.T) {
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+	registerIQ(httpmock.NewStringResponder(401, ""))
+
+	auditor, err := New(IQ, &types.Config{Server: "http://sillyplace.com:8090", User: "hashbrowns", Token: "secret", MaxRetries: 300})
+	assert.NoError(t, err)
+
+	_, err = auditor.Audit(entries, "testapp", "build")
+
+	assert.EqualError(t, err, "Unable to poll Nexus IQ Server for results, status code returned is: 401")
+	assert.Equal(t, 1, httpmock.GetCallCountInfo()["GET http://sillyplace.com:8090/"+statusURL])
+}
+
+func TestIQWarningAndFailingViolations(t *testing.T) {
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+	registerIQ(httpmock.NewStringResponder(200, pollingResult))
+	// A threat level 9 policy that only warns, and a threat level 5 one that failed the audit, which the policy report
+	// can't tell apart
+	httpmock.RegisterResponder("GET", "http://sillyplace.com:8090/api/v2/applications/testapp/reports/95c4c14e/policy",
+		httpmock.NewStringResponder(200, `{
+			"components": [
+				{
+					"hash": "9987ca4f73d5ea0e534d",
+					"pathnames": ["lib/foo.jar"],
+					"violations": [{"policyName": "Security-Warn", "policyThreatLevel": 9, "waived": false}]
+				},
+				{
+					"hash": "aaf4c61ddcc5e8a2dabe",
+					"pathnames": ["lib/hello.so"],
+					"violations": [{"policyName": "License-Fail", "policyThreatLevel": 5, "waived": false}]
+				}
+			]
+		}`))
+
+	auditor, err := New(IQ, &types.Config{Server: "http://sillyplace.com:8090", User: "hashbrowns", Token: "secret", MaxRetries: 1})
+	assert.NoError(t, err)
+
+	r, err := auditor.Audit(entries, "testapp", "build")
+
+	assert.NoError(t, err)
+	assert.Equal(t, report.OutcomeFailure, r.Outcome)
+	assert.True(t, r.ViolationsUnconfirmed)
+	assert.Equal(t, []report.Violation{
+		{Sha1: "9987ca4f73d5ea0e534d", Location: "lib/foo.jar", Reason: "Security-Warn", Severity: "critical", ThreatLevel: 9},
+		{Sha1: "aaf4c61ddcc5e8a2dabe", Location: "lib/hello.so", Reason: "License-Fail", Severity: "high", ThreatLevel: 5},
+	}, r.Violations)
+}
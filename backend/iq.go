@@ -0,0 +1,99 @@
+//
+// Copyright © 2020-present Sonatype Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+package backend
+
+import (
+	"strings"
+
+	"github.com/sirupsen/logrus"
+	"github.com/sonatype-nexus-community/go-sona-types/cyclonedx"
+	"github.com/sonatype-nexus-community/hashbrowns/iq"
+	"github.com/sonatype-nexus-community/hashbrowns/logger"
+	"github.com/sonatype-nexus-community/hashbrowns/progress"
+	"github.com/sonatype-nexus-community/hashbrowns/report"
+	"github.com/sonatype-nexus-community/hashbrowns/types"
+)
+
+// iqAuditor submits sha1s to Nexus IQ Server as a CycloneDX SBOM
+type iqAuditor struct {
+	config types.Config
+}
+
+func newIQ(config *types.Config) *iqAuditor {
+	return &iqAuditor{config: *config}
+}
+
+// SBOM returns the CycloneDX SBOM that sha1s are submitted to Nexus IQ Server as
+func SBOM(sha1s []cyclonedx.Sha1SBOM) string {
+	sbom := cyclonedx.Default(logger.GetLogger(0)).FromSHA1s(sha1s)
+	return strings.Replace(sbom, "\n", "", -1)
+}
+
+func (a *iqAuditor) Audit(sha1s []cyclonedx.Sha1SBOM, application string, stage string) (r report.Report, err error) {
+	config := a.config
+	config.Application = application
+	config.Stage = stage
+
+	log.WithField("entries", len(sha1s)).Info("Beginning to obtain SBOM")
+	task := progress.Start("Building SBOM")
+	task.Update("%d entries", len(sha1s))
+	sbom := SBOM(sha1s)
+	task.Done("%d entries, %s", len(sha1s), progress.Bytes(int64(len(sbom))))
+
+	log.WithFields(logger.Payload("sbom", sbom)).Trace("SBOM obtained")
+
+	log.WithField("entries", len(sha1s)).Info("Beginning to submit SBOM to Nexus IQ Server")
+	res, err := iq.AuditPackages(sbom, &config)
+	if err != nil {
+		log.WithField("error", err).Error("Unable to submit SBOM to Nexus IQ Server")
+
+		return
+	}
+	log.WithFields(logrus.Fields{
+		"policy_action": res.PolicyAction,
+		"is_error":      res.IsError,
+		"report_url":    res.ReportHTMLURL,
+	}).Trace("Obtained response from Nexus IQ Server")
+
+	r = report.Report{
+		Backend:      IQ,
+		Outcome:      report.OutcomePass,
+		PolicyAction: res.PolicyAction,
+		ReportURL:    res.ReportHTMLURL,
+	}
+	switch {
+	case res.IsError:
+		r.Outcome = report.OutcomeError
+		r.Error = res.ErrorMessage
+	case res.PolicyAction == "":
+		// Without a policy action there is no telling whether the audit passed, so it mustn't look like it did
+		r.Outcome = report.OutcomeError
+		r.Error = "Nexus IQ Server did not return a policy action"
+	case res.PolicyAction == "Failure":
+		r.Outcome = report.OutcomeFailure
+	}
+
+	// The policy report is a nice to have, the report URL has everything if it can't be fetched
+	if r.Outcome == report.OutcomeFailure && res.ReportDataURL != "" {
+		if r.Violations, err = iq.PolicyViolations(res.ReportDataURL, &config); err != nil {
+			log.WithField("error", err).Warn("Unable to obtain policy violations from Nexus IQ Server, see the report URL instead")
+			err = nil
+		}
+		r.ViolationsUnconfirmed = len(r.Violations) > 0
+	}
+
+	return
+}
@@ -0,0 +1,68 @@
+//
+// Copyright © 2020-present Sonatype Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+package backend
+
+import (
+	"fmt"
+
+	"github.com/sirupsen/logrus"
+	"github.com/sonatype-nexus-community/go-sona-types/cyclonedx"
+	"github.com/sonatype-nexus-community/hashbrowns/local"
+	"github.com/sonatype-nexus-community/hashbrowns/report"
+)
+
+// localAuditor checks sha1s against deny and allow lists loaded from disk, without Nexus IQ Server
+type localAuditor struct {
+	deny  local.List
+	allow local.List
+}
+
+func newLocal(denyLists []string, allowLists []string) (a *localAuditor, err error) {
+	if len(denyLists) == 0 {
+		return nil, fmt.Errorf("The %s backend needs at least one deny list, see usage for more information", Local)
+	}
+
+	log.WithFields(logrus.Fields{
+		"deny_lists":  denyLists,
+		"allow_lists": allowLists,
+	}).Info("Loading local deny and allow lists")
+	a = &localAuditor{}
+	if a.deny, err = local.LoadLists(denyLists); err != nil {
+		log.WithField("error", err).Error("Error loading deny lists")
+
+		return nil, err
+	}
+	if a.allow, err = local.LoadLists(allowLists); err != nil {
+		log.WithField("error", err).Error("Error loading allow lists")
+
+		return nil, err
+	}
+
+	return
+}
+
+func (a *localAuditor) Audit(sha1s []cyclonedx.Sha1SBOM, application string, stage string) (r report.Report, err error) {
+	r = report.Report{Backend: Local, Outcome: report.OutcomePass, PolicyAction: "None"}
+
+	r.Violations = local.Evaluate(sha1s, a.deny, a.allow)
+	log.WithField("violations", len(r.Violations)).Debug("Evaluated sha1s against local lists")
+	if len(r.Violations) > 0 {
+		r.Outcome = report.OutcomeFailure
+		r.PolicyAction = "Failure"
+	}
+
+	return
+}
@@ -0,0 +1,74 @@
+//
+// Copyright © 2020-present Sonatype Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+package backend
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/sonatype-nexus-community/go-sona-types/cyclonedx"
+	"github.com/sonatype-nexus-community/hashbrowns/local"
+	"github.com/sonatype-nexus-community/hashbrowns/report"
+)
+
+// MockAuditor gives every audit the same outcome without going anywhere, for testing pipelines and hashbrowns itself
+type MockAuditor struct {
+	// Outcome is what every audit ends in, failures have every entry as a violation
+	Outcome string
+
+	mu      sync.Mutex
+	audited [][]cyclonedx.Sha1SBOM
+}
+
+func newMock(outcome string) (*MockAuditor, error) {
+	switch outcome {
+	case "":
+		outcome = report.OutcomePass
+	case report.OutcomePass, report.OutcomeFailure, report.OutcomeError:
+	default:
+		return nil, fmt.Errorf("Unknown mock outcome %q, supported outcomes are: %s, %s, %s", outcome, report.OutcomePass, report.OutcomeFailure, report.OutcomeError)
+	}
+	return &MockAuditor{Outcome: outcome}, nil
+}
+
+// Audit remembers sha1s, and returns a report with the mock outcome
+func (m *MockAuditor) Audit(sha1s []cyclonedx.Sha1SBOM, application string, stage string) (r report.Report, err error) {
+	m.mu.Lock()
+	m.audited = append(m.audited, sha1s)
+	m.mu.Unlock()
+
+	r = report.Report{Backend: Mock, Outcome: m.Outcome, PolicyAction: "None"}
+	switch m.Outcome {
+	case report.OutcomeFailure:
+		r.PolicyAction = "Failure"
+		for _, v := range sha1s {
+			r.Violations = append(r.Violations, report.Violation{Sha1: v.Sha1, Location: v.Location, Reason: "Mock failure", Severity: local.DefaultSeverity})
+		}
+	case report.OutcomeError:
+		r.PolicyAction = ""
+		r.Error = "Mock error"
+	}
+
+	return
+}
+
+// Audited returns the sha1s of every audit so far, in order
+func (m *MockAuditor) Audited() [][]cyclonedx.Sha1SBOM {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return append([][]cyclonedx.Sha1SBOM{}, m.audited...)
+}
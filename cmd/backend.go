@@ -0,0 +1,62 @@
+//
+// Copyright © 2020-present Sonatype Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+package cmd
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/sonatype-nexus-community/hashbrowns/backend"
+	"github.com/sonatype-nexus-community/hashbrowns/types"
+	"github.com/spf13/pflag"
+)
+
+// auditor is the backend sha1s are audited with, set up by each command from --backend
+var auditor backend.Auditor
+
+// addBackendFlags adds the flags for choosing and setting up the backend, for any command that audits
+func addBackendFlags(pf *pflag.FlagSet) {
+	pf.StringVar(&config.Backend, "backend", "", fmt.Sprintf("Specify what to audit with, one of: %s (default %s, or %s with --deny-list)", strings.Join(backend.Names(), ", "), backend.IQ, backend.Local))
+	pf.StringSliceVar(&config.DenyLists, "deny-list", nil, "Check against these lists of known bad sha1s (text, CSV or JSON) with the local backend")
+	pf.StringSliceVar(&config.AllowLists, "allow-list", nil, "Never fail on sha1s in these lists (text, CSV or JSON), even if they are in a --deny-list")
+	pf.StringVar(&config.MockOutcome, "mock-outcome", "pass", "Specify the outcome of every audit with the mock backend, one of: pass, failure, error")
+}
+
+// checkBackendFlags checks the flags added by addBackendFlags make sense together, for any command that adds them
+func checkBackendFlags(flags *pflag.FlagSet) {
+	if flags.Changed("allow-list") && !flags.Changed("deny-list") {
+		panic(fmt.Errorf("Allow lists only apply to deny lists, see usage for more information"))
+	}
+}
+
+// backendName returns the backend chosen with --backend, falling back to local if there are deny lists, or otherwise
+// Nexus IQ Server
+func backendName(config *types.Config) string {
+	switch {
+	case config.Backend != "":
+		return config.Backend
+	case len(config.DenyLists) > 0:
+		return backend.Local
+	}
+	return backend.IQ
+}
+
+func newAuditor(config *types.Config) (backend.Auditor, error) {
+	name := backendName(config)
+	log.WithField("backend", name).Info("Setting up backend")
+
+	return backend.New(name, config)
+}
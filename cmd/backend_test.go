@@ -0,0 +1,42 @@
+//
+// Copyright © 2020-present Sonatype Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+package cmd
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCheckBackendFlagsAllowListWithoutDenyList(t *testing.T) {
+	origConfig := config
+	t.Cleanup(func() {
+		config = origConfig
+		// Flags stay changed between commands run in tests, so later ones would fail this check too
+		serveCmd.PersistentFlags().Lookup("allow-list").Changed = false
+		watchCmd.PersistentFlags().Lookup("allow-list").Changed = false
+	})
+
+	for _, args := range [][]string{
+		{"serve", "--allow-list=testdata/deny.txt"},
+		{"watch", "--path=testdata/before.txt", "--application=testapp", "--allow-list=testdata/deny.txt"},
+	} {
+		_, err := executeCommand(rootCmd, args...)
+
+		assert.NotNil(t, err, args[0])
+		assert.Equal(t, "Allow lists only apply to deny lists, see usage for more information", err.Error(), args[0])
+	}
+}
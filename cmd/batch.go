@@ -0,0 +1,155 @@
+//
+// Copyright © 2020-present Sonatype Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"sync"
+	"text/tabwriter"
+
+	"github.com/sirupsen/logrus"
+	"github.com/sonatype-nexus-community/hashbrowns/progress"
+	"github.com/sonatype-nexus-community/hashbrowns/report"
+	"github.com/sonatype-nexus-community/hashbrowns/types"
+	"gopkg.in/yaml.v2"
+)
+
+// manifest lists the audits to run with fry --manifest
+type manifest struct {
+	Audits []manifestEntry `yaml:"audits"`
+}
+
+// manifestEntry is a single path and the application it is audited against, stage and input-format are optional
+type manifestEntry struct {
+	Path        string `yaml:"path"`
+	Application string `yaml:"application"`
+	Stage       string `yaml:"stage"`
+	InputFormat string `yaml:"input-format"`
+}
+
+func readManifest(path string) (m manifest, err error) {
+	content, err := ioutil.ReadFile(path)
+	if err != nil {
+		return
+	}
+
+	if err = yaml.UnmarshalStrict(content, &m); err != nil {
+		return m, fmt.Errorf("Unable to parse manifest %s: %v", path, err)
+	}
+	if len(m.Audits) == 0 {
+		return m, fmt.Errorf("Manifest %s does not list any audits", path)
+	}
+	for i, v := range m.Audits {
+		if v.Path == "" || v.Path == stdinPath {
+			return m, fmt.Errorf("Audit %d in manifest %s needs a path to a file or directory", i+1, path)
+		}
+		if v.Application == "" {
+			return m, fmt.Errorf("Audit %d in manifest %s needs an application", i+1, path)
+		}
+	}
+
+	return
+}
+
+// entryConfig returns a copy of config with the path, application, stage and input format from entry
+func entryConfig(config *types.Config, entry manifestEntry) *types.Config {
+	c := *config
+	c.Path = entry.Path
+	c.Application = entry.Application
+	if entry.Stage != "" {
+		c.Stage = entry.Stage
+	}
+	if entry.InputFormat != "" {
+		c.InputFormat = entry.InputFormat
+	}
+	return &c
+}
+
+// doBatch runs every audit in the manifest, at most config.Concurrency at a time, and returns a report for each once
+// they are all done, in the same order as the manifest
+func doBatch(config *types.Config, out io.Writer) (reports []report.Report, err error) {
+	m, err := readManifest(config.Manifest)
+	if err != nil {
+		return
+	}
+
+	concurrency := config.Concurrency
+	if concurrency < 1 {
+		concurrency = 1
+	}
+
+	// Progress of audits running at the same time would draw over each other
+	if concurrency > 1 {
+		progress.Setup(ioutil.Discard, false)
+	}
+
+	log.WithFields(logrus.Fields{
+		"manifest":    config.Manifest,
+		"audits":      len(m.Audits),
+		"concurrency": concurrency,
+	}).Info("Beginning batch of audits")
+
+	reports = make([]report.Report, len(m.Audits))
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	for w := 0; w < concurrency; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				c := entryConfig(config, m.Audits[i])
+				buf := new(bytes.Buffer)
+				outcome, err := doAudit(c, buf)
+				reports[i] = newReport(c, outcome, err)
+
+				mu.Lock()
+				fmt.Fprintf(out, "== %s (%s) ==\n", c.Application, c.Path)
+				_, _ = buf.WriteTo(out)
+				mu.Unlock()
+			}
+		}()
+	}
+	for i := range m.Audits {
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+
+	return
+}
+
+// worstExitCode returns the highest exit code of reports, an error beats a policy failure, which beats a pass
+func worstExitCode(reports []report.Report) (exitCode int) {
+	for _, v := range reports {
+		if code := v.ExitCode(); code > exitCode {
+			exitCode = code
+		}
+	}
+	return
+}
+
+func writeBatchSummary(out io.Writer, reports []report.Report) error {
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "APPLICATION\tSTAGE\tPATH\tENTRIES\tRESULT\tREPORT")
+	for _, v := range reports {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%d\t%s\t%s\n", v.Application, v.Stage, v.Path, v.Entries, v.Summary(), v.ReportURL)
+	}
+	return w.Flush()
+}
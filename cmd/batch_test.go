@@ -0,0 +1,123 @@
+//
+// Copyright © 2020-present Sonatype Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+package cmd
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/jarcoal/httpmock"
+	"github.com/sonatype-nexus-community/hashbrowns/backend"
+	"github.com/sonatype-nexus-community/hashbrowns/logger"
+	"github.com/sonatype-nexus-community/hashbrowns/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReadManifest(t *testing.T) {
+	m, err := readManifest("testdata/manifest.yaml")
+
+	assert.NoError(t, err)
+	assert.Equal(t, []manifestEntry{
+		{Path: "testdata/emptyFile", Application: "testapp"},
+		{Path: "testdata/missingFile", Application: "testapp", Stage: "release"},
+	}, m.Audits)
+}
+
+func TestReadManifestMissingApplication(t *testing.T) {
+	dir, err := ioutil.TempDir("", "manifest")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "audits.yaml")
+	assert.NoError(t, ioutil.WriteFile(path, []byte("audits:\n  - path: build\n"), 0644))
+
+	_, err = readManifest(path)
+	assert.Equal(t, "Audit 1 in manifest "+path+" needs an application", err.Error())
+}
+
+func TestDoBatch(t *testing.T) {
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+
+	httpmock.RegisterResponder("GET", "http://sillyplace.com:8090/api/v2/applications?publicId=testapp",
+		httpmock.NewStringResponder(200, applicationsResponse))
+
+	httpmock.RegisterResponder("POST", "http://sillyplace.com:8090/api/v2/scan/applications/4bb67dcfc86344e3a483832f8c496419/sources/nancy?stageId=develop",
+		httpmock.NewStringResponder(202, thirdPartyAPIResultJSON))
+
+	httpmock.RegisterResponder("GET", "http://sillyplace.com:8090/api/v2/scan/applications/4bb67dcfc86344e3a483832f8c496419/status/9cee2b6366fc4d328edc318eae46b2cb",
+		httpmock.NewStringResponder(200, pollingResult))
+
+	c := &types.Config{
+		Server:      "http://sillyplace.com:8090",
+		Stage:       "develop",
+		MaxRetries:  300,
+		Manifest:    "testdata/manifest.yaml",
+		Concurrency: 2,
+	}
+	var err error
+	auditor, err = newAuditor(c)
+	assert.NoError(t, err)
+
+	reports, err := doBatch(c, ioutil.Discard)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, worstExitCode(reports))
+
+	out := new(bytes.Buffer)
+	assert.NoError(t, writeBatchSummary(out, reports))
+	assert.Equal(t, `APPLICATION  STAGE    PATH                  ENTRIES  RESULT                                                       REPORT
+testapp      develop  testdata/emptyFile    0        Pass                                                         http://sillyplace.com:8090/ui/links/application/test-app/report/95c4c14e
+testapp      release  testdata/missingFile  0        Error: stat testdata/missingFile: no such file or directory  
+`, out.String())
+}
+
+// TestDoBatchConcurrently runs audits that hash and parse at the same time, with every log level on, so running the
+// tests with -race catches anything they share without locking
+func TestDoBatchConcurrently(t *testing.T) {
+	dir, err := ioutil.TempDir("", "batch")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	assert.NoError(t, logger.Setup(logger.Options{File: filepath.Join(dir, "hashbrowns.log"), Level: 4}))
+
+	for _, name := range []string{"one", "two"} {
+		assert.NoError(t, os.MkdirAll(filepath.Join(dir, name, "lib"), os.ModePerm))
+		assert.NoError(t, ioutil.WriteFile(filepath.Join(dir, name, "lib", "foo.jar"), []byte(name), 0644))
+	}
+	shasums, err := filepath.Abs("testdata/before.txt")
+	assert.NoError(t, err)
+	manifest := filepath.Join(dir, "audits.yaml")
+	assert.NoError(t, ioutil.WriteFile(manifest, []byte(`audits:
+  - path: `+filepath.Join(dir, "one")+`
+    application: one
+  - path: `+filepath.Join(dir, "two")+`
+    application: two
+  - path: `+shasums+`
+    application: three
+`), 0644))
+
+	c := &types.Config{Backend: backend.Mock, Stage: "develop", Manifest: manifest, Concurrency: 3}
+	auditor, err = newAuditor(c)
+	assert.NoError(t, err)
+
+	reports, err := doBatch(c, ioutil.Discard)
+	assert.NoError(t, err)
+	assert.Equal(t, 0, worstExitCode(reports))
+	assert.Equal(t, []int{1, 1, 3}, []int{reports[0].Entries, reports[1].Entries, reports[2].Entries})
+}
@@ -0,0 +1,71 @@
+//
+// Copyright © 2020-present Sonatype Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+
+	"github.com/common-nighthawk/go-figure"
+	"github.com/sonatype-nexus-community/hashbrowns/buildversion"
+	"github.com/sonatype-nexus-community/hashbrowns/progress"
+)
+
+// stdoutIsTerminal and stderrIsTerminal report whether each is a terminal, rather than a pipe or file, replaced in tests
+var (
+	stdoutIsTerminal = func() bool { return isTerminal(os.Stdout) }
+	stderrIsTerminal = func() bool { return isTerminal(os.Stderr) }
+)
+
+func isTerminal(f *os.File) bool {
+	info, err := f.Stat()
+	if err != nil {
+		return false
+	}
+	return info.Mode()&os.ModeCharDevice != 0
+}
+
+// decorate reports whether to print decorative output, which is only wanted by someone watching at a terminal
+func decorate() bool {
+	return !config.Quiet && stdoutIsTerminal()
+}
+
+// progressWriter is where to print the progress of audits, kept off stdout so results can be piped into other tools
+func progressWriter() io.Writer {
+	if config.Quiet {
+		return ioutil.Discard
+	}
+	return os.Stderr
+}
+
+// setupDecorations prints the banner, and shows progress on stderr, redrawn in place if it is a terminal
+func setupDecorations() {
+	progress.Setup(progressWriter(), stderrIsTerminal())
+
+	if decorate() && !config.NoBanner {
+		printBanner()
+	}
+}
+
+// printBanner prints the name and version of hashbrowns, replaced in tests
+var printBanner = func() {
+	figure.NewFigure("Hashbrowns", "isometric1", true).Print()
+	figure.NewFigure("By Sonatype & Friends", "pepper", true).Print()
+
+	fmt.Println("Hashbrowns version: " + buildversion.BuildVersion)
+}
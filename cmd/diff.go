@@ -0,0 +1,98 @@
+//
+// Copyright © 2020-present Sonatype Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+package cmd
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+	"github.com/sonatype-nexus-community/hashbrowns/diff"
+	"github.com/sonatype-nexus-community/hashbrowns/parse"
+	"github.com/spf13/cobra"
+)
+
+var diffOutput string
+
+var diffInputFormat string
+
+// diffCmd represents the diff command
+var diffCmd = &cobra.Command{
+	Use:   "diff <before> <after>",
+	Short: "Show which binaries changed between two lists of sha1s",
+	Long: `Provided two files with sha1's and locations (or two SBOMs, with --input-format), this command will show which
+entries were added, removed, moved (same sha1, new location) or modified (same location, new sha1) going from the
+first to the second.
+
+This can be used to see exactly which binaries changed between two builds, before auditing them.`,
+	Args:          cobra.ExactArgs(2),
+	SilenceErrors: true,
+	RunE: func(cmd *cobra.Command, args []string) (err error) {
+		defer recoverAndPrintError(&err)
+
+		setupLogger(&config)
+
+		log.Info("Running Diff Command")
+
+		if diffOutput != outputText && diffOutput != outputJSON {
+			panic(fmt.Errorf("Unknown output %q, supported outputs are: %s, %s", diffOutput, outputText, outputJSON))
+		}
+
+		// A sha1 can be at more than one location, and each of them can change on its own
+		opts := parse.Options{Format: diffInputFormat, KeepDuplicates: true}
+
+		log.WithFields(logrus.Fields{
+			"before":       args[0],
+			"after":        args[1],
+			"input_format": diffInputFormat,
+		}).Info("Beginning parsing of files to compare")
+		before, err := parse.File(args[0], opts)
+		if err != nil {
+			panic(err)
+		}
+		after, err := parse.File(args[1], opts)
+		if err != nil {
+			panic(err)
+		}
+
+		result := diff.Compare(before, after)
+		log.WithFields(diffFields(result)).Debug("Compared sha1s")
+
+		if diffOutput == outputJSON {
+			return result.WriteJSON(cmd.OutOrStdout())
+		}
+		return result.WriteText(cmd.OutOrStdout())
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(diffCmd)
+
+	pf := diffCmd.PersistentFlags()
+
+	pf.StringVar(&diffInputFormat, "input-format", parse.FormatShasum, fmt.Sprintf("Specify format of both files, one of: %s", strings.Join(parse.Formats(), ", ")))
+	pf.StringVarP(&diffOutput, "output", "o", outputText, "Specify output format, one of: text, json")
+}
+
+// diffFields describes a diff as fields to log, counting each kind of change rather than listing every entry
+func diffFields(result diff.Result) logrus.Fields {
+	return logrus.Fields{
+		"added":    len(result.Added),
+		"removed":  len(result.Removed),
+		"moved":    len(result.Moved),
+		"modified": len(result.Modified),
+	}
+}
@@ -0,0 +1,73 @@
+//
+// Copyright © 2020-present Sonatype Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+package cmd
+
+import (
+	"testing"
+
+	"github.com/sirupsen/logrus"
+	"github.com/sonatype-nexus-community/hashbrowns/diff"
+	"github.com/sonatype-nexus-community/hashbrowns/parse"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDiffCommandText(t *testing.T) {
+	output, err := executeCommand(rootCmd, "diff", "testdata/before.txt", "testdata/after.txt", "--output=text")
+
+	assert.Nil(t, err)
+	assert.Equal(t, `0 added, 1 removed, 1 moved, 1 modified
+  - da39a3ee5e6b4b0d3255bfef95601890afd80709  lib/removed.jar
+  > 9987ca4f73d5ea0e534dfbf19238552df4de507e  build-1/lib/foo.jar -> build-2/lib/foo.jar
+  ~ 2a72a07fbc9de22308d12a32f7d33504349e63c9 -> aaf4c61ddcc5e8a2dabede0f3b482cd9aea9434d  lib/bar.jar
+`, output)
+}
+
+func TestDiffCommandJSON(t *testing.T) {
+	output, err := executeCommand(rootCmd, "diff", "testdata/before.txt", "testdata/after.txt", "--output=json")
+
+	assert.Nil(t, err)
+	assert.JSONEq(t, `{
+		"added": [],
+		"removed": [{"sha1": "da39a3ee5e6b4b0d3255bfef95601890afd80709", "location": "lib/removed.jar"}],
+		"moved": [{"sha1": "9987ca4f73d5ea0e534dfbf19238552df4de507e", "oldLocation": "build-1/lib/foo.jar", "newLocation": "build-2/lib/foo.jar"}],
+		"modified": [{"location": "lib/bar.jar", "oldSha1": "2a72a07fbc9de22308d12a32f7d33504349e63c9", "newSha1": "aaf4c61ddcc5e8a2dabede0f3b482cd9aea9434d"}]
+	}`, output)
+}
+
+func TestDiffCommandSha1AtTwoLocations(t *testing.T) {
+	output, err := executeCommand(rootCmd, "diff", "testdata/before-duplicates.txt", "testdata/after-duplicates.txt", "--output=text")
+
+	assert.Nil(t, err)
+	assert.Equal(t, `0 added, 0 removed, 0 moved, 1 modified
+  ~ aaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa -> bbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbb  b/foo.jar
+`, output)
+}
+
+func TestDiffCommandMissingArgs(t *testing.T) {
+	_, err := executeCommand(rootCmd, "diff", "testdata/before.txt")
+
+	assert.NotNil(t, err)
+	assert.Equal(t, "accepts 2 arg(s), received 1", err.Error())
+}
+
+func TestDiffFieldsCountsChanges(t *testing.T) {
+	before, err := parse.File("testdata/before.txt", parse.Options{})
+	assert.NoError(t, err)
+	after, err := parse.File("testdata/after.txt", parse.Options{})
+	assert.NoError(t, err)
+
+	assert.Equal(t, logrus.Fields{"added": 0, "removed": 1, "moved": 1, "modified": 1}, diffFields(diff.Compare(before, after)))
+}
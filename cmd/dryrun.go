@@ -0,0 +1,131 @@
+//
+// Copyright © 2020-present Sonatype Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"text/tabwriter"
+
+	"github.com/sirupsen/logrus"
+	"github.com/sonatype-nexus-community/go-sona-types/cyclonedx"
+	"github.com/sonatype-nexus-community/hashbrowns/backend"
+	"github.com/sonatype-nexus-community/hashbrowns/iq"
+	"github.com/sonatype-nexus-community/hashbrowns/progress"
+	"github.com/sonatype-nexus-community/hashbrowns/types"
+)
+
+// dryRunSample is how many of the entries to submit are printed by a dry run
+const dryRunSample = 10
+
+// dryRun is what an audit would submit to Nexus IQ Server
+type dryRun struct {
+	URL         string
+	Application string
+	InternalID  string
+	Stage       string
+	Entries     int
+	Submit      []cyclonedx.Sha1SBOM
+	SBOMSize    int
+}
+
+func checkDryRunFlags() {
+	if config.ResolveApplication && !config.DryRun {
+		panic(fmt.Errorf("Resolving the application only applies to dry runs, see usage for more information"))
+	}
+	if !config.DryRun {
+		return
+	}
+	if config.Manifest != "" {
+		panic(fmt.Errorf("Dry runs can't be done with a manifest, see usage for more information"))
+	}
+	if backendName(&config) != backend.IQ {
+		panic(fmt.Errorf("Dry runs show what would be submitted to Nexus IQ Server, so only apply to the iq backend"))
+	}
+}
+
+// doDryRun does everything an audit does up to building the SBOM, and then returns what would have been submitted
+// to Nexus IQ Server instead of submitting it. No state is saved, so a dry run doesn't change what --diff compares to.
+func doDryRun(config *types.Config, out io.Writer) (d dryRun, err error) {
+	sha1s, submit, err := doPrepare(config, out)
+	if err != nil {
+		return
+	}
+
+	d = dryRun{
+		URL:         iq.SubmitURL(config.Server, fmt.Sprintf("<internal ID of %s>", config.Application), config.Stage),
+		Application: config.Application,
+		Stage:       config.Stage,
+		Entries:     len(sha1s),
+		Submit:      submit,
+	}
+
+	task := progress.Start("Building SBOM")
+	task.Update("%d entries", len(submit))
+	d.SBOMSize = len(backend.SBOM(submit))
+	task.Done("%d entries, %s", len(submit), progress.Bytes(int64(d.SBOMSize)))
+
+	if config.ResolveApplication {
+		log.WithField("application_id", config.Application).Debug("Resolving internal application ID for dry run")
+		if d.InternalID, err = iq.InternalApplicationID(config); err != nil {
+			return
+		}
+		d.URL = iq.SubmitURL(config.Server, d.InternalID, config.Stage)
+	}
+
+	log.WithFields(logrus.Fields{
+		"url":       d.URL,
+		"entries":   d.Entries,
+		"submit":    len(d.Submit),
+		"sbom_size": d.SBOMSize,
+	}).Info("Finished dry run")
+
+	return
+}
+
+// printDryRun prints what a dry run would have submitted, with a sample of the entries
+func printDryRun(w io.Writer, d dryRun) {
+	fmt.Fprintln(w, "Dry run, nothing was submitted to Nexus IQ Server")
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(tw, "Target URL:\t%s\n", d.URL)
+	if d.InternalID != "" {
+		fmt.Fprintf(tw, "Application:\t%s (internal ID %s)\n", d.Application, d.InternalID)
+	} else {
+		fmt.Fprintf(tw, "Application:\t%s (internal ID not resolved, use --resolve-application to look it up)\n", d.Application)
+	}
+	fmt.Fprintf(tw, "Stage:\t%s\n", d.Stage)
+	fmt.Fprintf(tw, "Components:\t%d of %d entries\n", len(d.Submit), d.Entries)
+	fmt.Fprintf(tw, "SBOM size:\t%s\n", progress.Bytes(int64(d.SBOMSize)))
+	_ = tw.Flush()
+
+	if len(d.Submit) == 0 {
+		return
+	}
+
+	fmt.Fprintln(w)
+	sample := d.Submit
+	if len(sample) > dryRunSample {
+		sample = sample[:dryRunSample]
+	}
+	fmt.Fprintf(w, "Sample of %d entries:\n", len(sample))
+	for _, s := range sample {
+		fmt.Fprintf(w, "  %s  %s\n", s.Sha1, s.Location)
+	}
+	if more := len(d.Submit) - len(sample); more > 0 {
+		fmt.Fprintf(w, "  ... and %d more\n", more)
+	}
+}
@@ -18,23 +18,46 @@ package cmd
 import (
 	"errors"
 	"fmt"
+	"io"
 	"os"
 	"strings"
+	"time"
 
 	"github.com/sirupsen/logrus"
 	"github.com/sonatype-nexus-community/go-sona-types/cyclonedx"
-	"github.com/sonatype-nexus-community/hashbrowns/iq"
+	"github.com/sonatype-nexus-community/hashbrowns/backend"
+	"github.com/sonatype-nexus-community/hashbrowns/diff"
+	"github.com/sonatype-nexus-community/hashbrowns/filter"
+	"github.com/sonatype-nexus-community/hashbrowns/hasher"
+	"github.com/sonatype-nexus-community/hashbrowns/location"
 	"github.com/sonatype-nexus-community/hashbrowns/logger"
 	"github.com/sonatype-nexus-community/hashbrowns/parse"
+	"github.com/sonatype-nexus-community/hashbrowns/progress"
+	"github.com/sonatype-nexus-community/hashbrowns/report"
 	"github.com/sonatype-nexus-community/hashbrowns/types"
 
 	"github.com/spf13/cobra"
 	"github.com/spf13/pflag"
 )
 
-var log *logrus.Logger
+var log = logger.Logger()
 
-var sbomCreator *cyclonedx.CycloneDX
+// stdinPath is the value of --path that reads the sha1 list from stdin
+const stdinPath = "-"
+
+var stdin io.Reader = os.Stdin
+
+// hashCache is set when --cache-dir is used while hashing a directory, so stats can be shown at the end of a run
+var hashCache *hasher.Cache
+
+// stdinIsPipe reports whether something is being piped to hashbrowns, replaced in tests
+var stdinIsPipe = func() bool {
+	info, err := os.Stdin.Stat()
+	if err != nil {
+		return false
+	}
+	return info.Mode()&os.ModeCharDevice == 0
+}
 
 // fryCmd represents the fry command
 var fryCmd = &cobra.Command{
@@ -45,35 +68,66 @@ var fryCmd = &cobra.Command{
 This can be used to audit generic environments for matches to known hashes that do not meet your org's policy.`,
 	SilenceErrors: true,
 	RunE: func(cmd *cobra.Command, args []string) (err error) {
-		defer func() {
-			if r := recover(); r != nil {
-				var ok bool
-				err, ok = r.(error)
-				if !ok {
-					err = fmt.Errorf("pkg: %v", r)
-				}
-
-				logger.PrintErrorAndLogLocation(err)
-			}
-		}()
+		defer recoverAndPrintError(&err)
 
 		fflags := cmd.Flags()
 
+		checkBackendFlags(fflags)
 		checkRequiredFlags(fflags)
+		checkDryRunFlags()
 
-		log = logger.GetLogger("", config.LogLevel)
-
-		sbomCreator = cyclonedx.Default(log)
+		setupLogger(&config)
 
 		log.Info("Running Fry Command")
 
-		sha1s, err := doParseSha1List(&config)
+		if auditor, err = newAuditor(&config); err != nil {
+			panic(err)
+		}
+
+		if err = loadWaivers(&config); err != nil {
+			panic(err)
+		}
+
+		if err = openHashCache(&config); err != nil {
+			panic(err)
+		}
+
+		if config.DryRun {
+			d, err := doDryRun(&config, progressWriter())
+			if err != nil {
+				panic(err)
+			}
+			printDryRun(os.Stdout, d)
+
+			if err = saveHashCache(); err != nil {
+				panic(err)
+			}
+			return nil
+		}
+
+		sinks, err := parseOutputs(&config)
 		if err != nil {
 			panic(err)
 		}
+		out := progressWriter()
+
+		var reports []report.Report
+		if config.Manifest != "" {
+			if reports, err = doBatch(&config, out); err != nil {
+				panic(err)
+			}
+		} else {
+			// An audit that can't be done is an error report, as it is in a batch, so outputs are still written
+			outcome, auditErr := doAudit(&config, out)
+			reports = []report.Report{newReport(&config, outcome, auditErr)}
+		}
 
 		var exitCode int
-		if exitCode, err = doCycloneDxAndIQ(sha1s); err != nil {
+		if exitCode, err = writeOutputs(&config, sinks, reports); err != nil {
+			panic(err)
+		}
+
+		if err = saveHashCache(); err != nil {
 			panic(err)
 		}
 
@@ -81,84 +135,530 @@ This can be used to audit generic environments for matches to known hashes that
 			return
 		}
 
-		return fmt.Errorf("Non zero exit code: %d", exitCode)
+		return ExitError{Code: exitCode}
 	},
 }
 
+// ExitError is returned by commands that need hashbrowns to exit with a specific non zero code
+type ExitError struct {
+	Code int
+}
+
+func (e ExitError) Error() string {
+	return fmt.Sprintf("Non zero exit code: %d", e.Code)
+}
+
+// auditOutcome is the result of parsing, filtering and submitting the sha1s for one path and application
+type auditOutcome struct {
+	Entries   int
+	Submitted int
+	Skipped   bool
+	Audited   []cyclonedx.Sha1SBOM
+	Report    report.Report
+}
+
+// doAudit runs the whole pipeline for config, from parsing the sha1s through to the Nexus IQ Server result
+func doAudit(config *types.Config, out io.Writer) (outcome auditOutcome, err error) {
+	sha1s, submit, err := doPrepare(config, out)
+	if err != nil {
+		return
+	}
+	outcome.Entries = len(sha1s)
+	outcome.Submitted = len(submit)
+	outcome.Audited = submit
+
+	if config.NewOnly && len(submit) == 0 {
+		outcome.Skipped = true
+	} else if outcome.Report, err = auditor.Audit(submit, config.Application, config.Stage); err != nil {
+		return
+	}
+	outcome.Report = applyWaivers(config, outcome.Report)
+
+	if config.Diff || config.NewOnly {
+		err = doSaveDiffState(config, sha1s, outcome.Report)
+	}
+
+	return
+}
+
+// doPrepare parses, filters and tidies up the sha1s for config, returning all of them, and the ones left to submit
+// once unchanged and waived entries are taken out
+func doPrepare(config *types.Config, out io.Writer) (sha1s []cyclonedx.Sha1SBOM, submit []cyclonedx.Sha1SBOM, err error) {
+	if sha1s, err = doParseSha1List(config); err != nil {
+		return
+	}
+	if sha1s, err = doFilter(config, out, sha1s); err != nil {
+		return
+	}
+	if sha1s, err = doRewriteLocations(config, out, sha1s); err != nil {
+		return
+	}
+
+	submit = sha1s
+	if config.Diff || config.NewOnly {
+		if submit, err = doDiff(config, out, sha1s); err != nil {
+			return
+		}
+	}
+	submit = doWaiveEntries(config, out, submit)
+	submit = doRedactLocations(config, submit)
+
+	return
+}
+
 func init() {
 	rootCmd.AddCommand(fryCmd)
 
 	pf := fryCmd.PersistentFlags()
 
-	pf.StringVar(&config.Path, "path", "", "Path to file with sha1s (required)")
+	pf.StringVar(&config.Path, "path", "", "Path to file with sha1s, directory to hash, or - to read from stdin (required unless piping to stdin)")
+	addIQFlags(pf)
+	addApplicationFlag(pf)
+	addBackendFlags(pf)
+	addWaiverFlags(pf)
+	pf.StringVar(&config.InputFormat, "input-format", parse.FormatShasum, fmt.Sprintf("Specify format of file at path, one of: %s", strings.Join(parse.Formats(), ", ")))
+	pf.StringVar(&config.CSVSha1Col, "csv-sha1-column", "sha1", "Specify CSV header name or zero based index of the sha1 column")
+	pf.StringVar(&config.CSVPathCol, "csv-path-column", "path", "Specify CSV header name or zero based index of the path column")
+	pf.IntVar(&config.ArchiveDepth, "archive-depth", 0, "Specify how many levels of nested jar, war, zip and tar archives to hash inside of when path is a directory")
+	pf.StringVar(&config.CacheDir, "cache-dir", "", "Directory to keep a cache of file hashes in, so unchanged files are not rehashed when path is a directory")
+	pf.BoolVar(&config.ClearCache, "clear-cache", false, "Discard any existing hash cache in --cache-dir before hashing")
+	addFilterFlags(pf)
+	addLocationFlags(pf)
+	pf.BoolVar(&config.Diff, "diff", false, "Report what was added, removed, moved or modified since the last --diff run for this application and stage")
+	pf.BoolVar(&config.NewOnly, "new-only", false, "Only submit entries added or modified since the last run (implies --diff), so only newly introduced components can fail the audit")
+	pf.StringVar(&config.StateDir, "state-dir", "", "Directory to keep the sha1s from the last --diff run in (default \"~/.hashbrowns/state\")")
+	pf.StringVar(&config.Manifest, "manifest", "", "YAML file listing the path, application and stage of many audits to run in one go, instead of --path and --application")
+	pf.IntVar(&config.Concurrency, "concurrency", 4, "Specify how many audits from --manifest to run at the same time")
+	pf.BoolVar(&config.DryRun, "dry-run", false, "Parse, filter and build the SBOM, then print what would be submitted to Nexus IQ Server without submitting it")
+	pf.BoolVar(&config.ResolveApplication, "resolve-application", false, "Look up the internal ID of the application on Nexus IQ Server during a --dry-run")
+	pf.StringArrayVarP(&config.Outputs, "output", "o", nil, fmt.Sprintf("Specify output format, one of: %s, as format=path to write it to a file, can be given more than once (default text)", strings.Join(outputs(), ", ")))
+	pf.StringVar(&config.OutputFile, "output-file", "", "Write outputs that aren't given a path to this file instead of stdout")
+}
+
+// addIQFlags adds the flags needed to submit to Nexus IQ Server, for any command that does so
+func addIQFlags(pf *pflag.FlagSet) {
 	pf.StringVar(&config.User, "user", "admin", "Specify Nexus IQ username for request")
 	pf.StringVar(&config.Token, "token", "admin123", "Specify Nexus IQ token/password for request")
 	pf.StringVar(&config.Server, "server-url", "http://localhost:8070", "Specify Nexus IQ Server URL")
-	pf.StringVar(&config.Application, "application", "", "Specify application ID for request (required)")
 	pf.StringVar(&config.Stage, "stage", "develop", "Specify stage for application")
 	pf.IntVar(&config.MaxRetries, "max-retries", 300, "Specify maximum number of tries to poll Nexus IQ Server")
 }
 
+// addApplicationFlag adds the flag for the application to audit against, for any command that audits a single one
+func addApplicationFlag(pf *pflag.FlagSet) {
+	pf.StringVar(&config.Application, "application", "", "Specify application ID for request (required)")
+}
+
+// addFilterFlags adds the flags used to narrow down what is submitted, for any command that does so
+func addFilterFlags(pf *pflag.FlagSet) {
+	pf.StringSliceVar(&config.FilterPresets, "filter", nil, fmt.Sprintf("Only submit artifacts for these ecosystems, any of: %s", strings.Join(filter.PresetNames(), ", ")))
+	pf.StringSliceVar(&config.FilterExts, "include-ext", nil, "Only submit files with these extensions, in addition to any --filter presets")
+	pf.Int64Var(&config.MinSize, "min-size", 0, "Skip files on disk (or archives they are nested in) smaller than this many bytes")
+	pf.Int64Var(&config.MaxSize, "max-size", 0, "Skip files on disk (or archives they are nested in) larger than this many bytes")
+}
+
+// addLocationFlags adds the flags used to tidy up locations before they are submitted, for any command that does so
+func addLocationFlags(pf *pflag.FlagSet) {
+	pf.StringSliceVar(&config.Excludes, "exclude", nil, "Skip entries with locations matching these globs, where ** matches across directories")
+	pf.StringSliceVar(&config.StripPrefixes, "strip-prefix", nil, "Remove the first of these prefixes that a location starts with")
+	pf.StringArrayVar(&config.PathRewrites, "path-rewrite", nil, "Rewrite locations with regex=replacement, after stripping prefixes, can be given more than once")
+	pf.StringVar(&config.RedactPaths, "redact-paths", "", fmt.Sprintf("Submit only part of each location, one of: %s", strings.Join(location.RedactModes(), ", ")))
+}
+
+// recoverAndPrintError turns a panic in a command into the error it returns, and lets the user know where to look
+func recoverAndPrintError(err *error) {
+	if r := recover(); r != nil {
+		var ok bool
+		*err, ok = r.(error)
+		if !ok {
+			*err = fmt.Errorf("pkg: %v", r)
+		}
+
+		logger.PrintErrorAndLogLocation(*err)
+	}
+}
+
 func checkRequiredFlags(flags *pflag.FlagSet) {
-	if !flags.Changed("path") {
+	if flags.Changed("manifest") {
+		return
+	}
+	if !flags.Changed("path") && !stdinIsPipe() {
 		panic(fmt.Errorf("Path not set, see usage for more information"))
 	}
-	if !flags.Changed("application") {
+	checkApplicationFlag(flags)
+}
+
+func checkApplicationFlag(flags *pflag.FlagSet) {
+	if !flags.Changed("application") && backendName(&config) == backend.IQ {
 		panic(fmt.Errorf("Application not set, see usage for more information"))
 	}
 }
 
 func doParseSha1List(config *types.Config) (sha1s []cyclonedx.Sha1SBOM, err error) {
+	opts := parse.Options{
+		Format:        config.InputFormat,
+		CSVSha1Column: config.CSVSha1Col,
+		CSVPathColumn: config.CSVPathCol,
+	}
+
+	if config.Path == "" || config.Path == stdinPath {
+		log.WithField("input_format", config.InputFormat).Info("Beginning parsing of stdin into sha1 type")
+		sha1s, err = parse.Input(stdin, opts)
+		if err != nil {
+			log.WithField("error", err).Error("Error parsing stdin into sha1 type")
+
+			return
+		}
+		log.WithField("entries", len(sha1s)).Debug("Obtained sha1 struct from stdin")
+
+		return
+	}
+
 	log.WithField("path", config.Path).Info("Checking for existence of path to sha1 file")
-	if _, err = os.Stat(config.Path); os.IsNotExist(err) {
+	info, err := os.Stat(config.Path)
+	if os.IsNotExist(err) {
 		log.WithField("error", err).Error("Path does not exist, returning")
 
 		return
 	}
 
-	log.WithField("path", config.Path).Info("Beginning parsing of file into sha1 type")
-	sha1s, err = parse.Sha1File(config.Path)
+	if err == nil && info.IsDir() {
+		log.WithFields(logrus.Fields{
+			"path":          config.Path,
+			"archive_depth": config.ArchiveDepth,
+		}).Info("Path is a directory, beginning hashing of files in it")
+		task, onFile := hashProgress()
+		sha1s, err = hasher.Dir(config.Path, hasher.Options{ArchiveDepth: config.ArchiveDepth, Cache: hashCache, OnFile: onFile})
+		task.Done("%d entries", len(sha1s))
+		if err != nil {
+			log.WithField("error", err).Error("Error hashing files in directory")
+
+			return
+		}
+		sha1s = parse.RemoveDuplicates(sha1s)
+		log.WithField("entries", len(sha1s)).Debug("Obtained sha1 struct from hashing directory")
+
+		return
+	}
+
+	log.WithFields(logrus.Fields{
+		"path":         config.Path,
+		"input_format": config.InputFormat,
+	}).Info("Beginning parsing of file into sha1 type")
+	sha1s, err = parse.File(config.Path, opts)
 	if err != nil {
 		log.WithField("error", err).Error("Error parsing sha1 file into sha1 type")
 
 		return
 	}
-	log.WithField("sha1s", sha1s).Debug("Obtained sha1 struct from ParseSha1File")
+	log.WithField("entries", len(sha1s)).Debug("Obtained sha1 struct from parsed file")
+
+	return
+}
+
+// hashProgress starts showing the progress of hashing a directory, updated by the hasher.Options.OnFile it returns
+func hashProgress() (*progress.Task, func(string, int64)) {
+	task := progress.Start("Hashing")
+	start := time.Now()
+	var files, size int64
+	return task, func(path string, n int64) {
+		files++
+		size += n
+		seconds := time.Since(start).Seconds()
+		task.Update("%d files (%.0f/s), %s (%s/s)", files, float64(files)/seconds, progress.Bytes(size), progress.Bytes(int64(float64(size)/seconds)))
+	}
+}
+
+func filterOptions(config *types.Config) filter.Options {
+	opts := filter.Options{
+		Presets:    config.FilterPresets,
+		Extensions: config.FilterExts,
+		MinSize:    config.MinSize,
+		MaxSize:    config.MaxSize,
+	}
+	if config.LibrariesOnly {
+		opts.Extensions = append(append([]string{}, config.FilterExts...), filter.LibraryExtensions...)
+	}
+	return opts
+}
+
+func doFilter(config *types.Config, out io.Writer, sha1s []cyclonedx.Sha1SBOM) (kept []cyclonedx.Sha1SBOM, err error) {
+	opts := filterOptions(config)
+	if !opts.Enabled() {
+		return sha1s, nil
+	}
+
+	log.WithField("filter", opts).Info("Beginning filtering of sha1s")
+	kept, filtered, unsized, err := filter.Apply(sha1s, opts)
+	if err != nil {
+		log.WithField("error", err).Error("Error filtering sha1s")
+
+		return
+	}
+
+	fmt.Fprintf(out, "Filtered out %d of %d entries, %d remaining\n", filtered, len(sha1s), len(kept))
+	if unsized > 0 {
+		fmt.Fprintf(out, "Size filters were not applied to %d entries that aren't files on disk\n", unsized)
+	}
 
 	return
 }
 
-func doCycloneDxAndIQ(sha1s []cyclonedx.Sha1SBOM) (exitCode int, err error) {
-	log.WithField("sha1s", sha1s).Info("Beginning to obtain SBOM")
-	sbom := sbomCreator.FromSHA1s(sha1s)
-	log.Info("Removing newlines from sbom")
-	sbom = strings.Replace(sbom, "\n", "", -1)
+func doRewriteLocations(config *types.Config, out io.Writer, sha1s []cyclonedx.Sha1SBOM) (kept []cyclonedx.Sha1SBOM, err error) {
+	opts := location.Options{
+		Excludes:      config.Excludes,
+		StripPrefixes: config.StripPrefixes,
+		Rewrites:      config.PathRewrites,
+		Redact:        config.RedactPaths,
+	}
+	if err = opts.Validate(); err != nil {
+		return
+	}
+	// Diffs and waivers match against locations, so they are only redacted by doRedactLocations once they're done
+	opts.Redact = ""
+	if !opts.Enabled() {
+		return sha1s, nil
+	}
+
+	log.WithField("locations", opts).Info("Beginning rewriting of locations")
+	kept, excluded, err := location.Apply(sha1s, opts)
+	if err != nil {
+		log.WithField("error", err).Error("Error rewriting locations")
 
-	log.WithField("sbom", sbom).Trace("SBOM obtained")
+		return
+	}
+
+	if excluded > 0 {
+		fmt.Fprintf(out, "Excluded %d of %d entries, %d remaining\n", excluded, len(sha1s), len(kept))
+	}
 
-	log.WithField("sbom", sbom).Info("Beginning to submit SBOM to Nexus IQ Server")
-	res, err := iq.AuditPackages(sbom, &config)
+	return
+}
+
+// doRedactLocations redacts the locations of the sha1s about to be submitted, if asked to
+func doRedactLocations(config *types.Config, sha1s []cyclonedx.Sha1SBOM) []cyclonedx.Sha1SBOM {
+	if config.RedactPaths == "" {
+		return sha1s
+	}
+
+	log.WithFields(logrus.Fields{
+		"redact_paths": config.RedactPaths,
+		"entries":      len(sha1s),
+	}).Info("Redacting locations")
+	return location.Redact(sha1s, config.RedactPaths)
+}
+
+func stateDir(config *types.Config) (string, error) {
+	if config.StateDir != "" {
+		return config.StateDir, nil
+	}
+	return diff.DefaultStateDir()
+}
+
+func doDiff(config *types.Config, out io.Writer, sha1s []cyclonedx.Sha1SBOM) (submit []cyclonedx.Sha1SBOM, err error) {
+	dir, err := stateDir(config)
 	if err != nil {
-		log.WithField("error", err).Error("Unable to submit SBOM to Nexus IQ Server")
+		return
+	}
+
+	log.WithFields(logrus.Fields{
+		"state_dir":   dir,
+		"application": config.Application,
+		"stage":       config.Stage,
+	}).Info("Loading sha1s from last run")
+	previous, ok, err := diff.LoadState(dir, config.Application, config.Stage)
+	if err != nil {
+		log.WithField("error", err).Error("Error loading sha1s from last run")
+
+		return
+	}
+
+	result := diff.Compare(previous, sha1s)
+	log.WithFields(diffFields(result)).Debug("Compared sha1s to last run")
+
+	if !ok {
+		fmt.Fprintf(out, "No previous run found for application %s at stage %s, all %d entries are new\n", config.Application, config.Stage, len(sha1s))
+	} else {
+		fmt.Fprint(out, "Changes since last run: ")
+		if err = result.WriteText(out); err != nil {
+			return
+		}
+	}
 
-		panic(err)
+	if config.NewOnly {
+		return result.Introduced(), nil
 	}
-	log.WithField("res", res).Trace("Obtained response from Nexus IQ Server")
+	return sha1s, nil
+}
 
-	fmt.Println()
-	if res.IsError {
-		log.WithField("err", res.ErrorMessage).Error("Nexus IQ Server responded with an error")
-		return 2, errors.New(res.ErrorMessage)
+// doSaveDiffState remembers the entries of sha1s that r doesn't show a problem with, so the next --diff run only treats
+// entries that passed as unchanged, and anything that failed, was waived or wasn't audited is audited again
+func doSaveDiffState(config *types.Config, sha1s []cyclonedx.Sha1SBOM, r report.Report) (err error) {
+	passed, ok := passedEntries(sha1s, r)
+	if !ok {
+		log.WithFields(logrus.Fields{
+			"application": config.Application,
+			"stage":       config.Stage,
+			"outcome":     r.Outcome,
+		}).Info("Not saving sha1s for next run, as the audit didn't say which entries passed")
+
+		return
 	}
+	passed = unwaivedEntries(config, passed, r)
+
+	dir, err := stateDir(config)
+	if err != nil {
+		return
+	}
+
+	log.WithFields(logrus.Fields{
+		"state_dir":   dir,
+		"application": config.Application,
+		"stage":       config.Stage,
+		"entries":     len(passed),
+	}).Info("Saving sha1s for next run")
+	if err = diff.SaveState(dir, config.Application, config.Stage, passed); err != nil {
+		log.WithField("error", err).Error("Error saving sha1s for next run")
+	}
+
+	return
+}
+
+// passedEntries returns the entries of sha1s that passed the audit in r, all of them if it passed, the ones without a
+// violation if it failed, and false if it had an error or failed without saying why
+func passedEntries(sha1s []cyclonedx.Sha1SBOM, r report.Report) (passed []cyclonedx.Sha1SBOM, ok bool) {
+	switch r.Outcome {
+	case report.OutcomeError:
+		return nil, false
+	case report.OutcomeFailure:
+		if len(r.Violations) == 0 {
+			return nil, false
+		}
+	}
+
+	for _, v := range sha1s {
+		if !violates(v, r.Violations) {
+			passed = append(passed, v)
+		}
+	}
+	return passed, true
+}
+
+// violates reports whether entry is one of violations, matching on the sha1 alone so an entry that failed is audited
+// again wherever it is
+func violates(entry cyclonedx.Sha1SBOM, violations []report.Violation) bool {
+	for _, v := range violations {
+		if report.Sha1Matches(entry.Sha1, v.Sha1) {
+			return true
+		}
+	}
+	return false
+}
+
+func openHashCache(config *types.Config) (err error) {
+	if config.CacheDir == "" {
+		return
+	}
+
+	log.WithFields(logrus.Fields{
+		"cache_dir":   config.CacheDir,
+		"clear_cache": config.ClearCache,
+	}).Info("Opening hash cache")
+	if hashCache, err = hasher.OpenCache(config.CacheDir, config.ClearCache); err != nil {
+		log.WithField("error", err).Error("Error opening hash cache")
+	}
+
+	return
+}
+
+// saveHashCache writes the hash cache back to disk, and prints how well it did
+func saveHashCache() (err error) {
+	if hashCache == nil {
+		return
+	}
+
+	if err = hashCache.Save(); err != nil {
+		log.WithField("error", err).Error("Error saving hash cache")
+
+		return
+	}
+	fmt.Fprintf(progressWriter(), "Hash cache: %d hits, %d misses\n", hashCache.Hits, hashCache.Misses)
+
+	return
+}
+
+// newReport turns the outcome of doAudit for config into a report, err being any error doAudit returned
+func newReport(config *types.Config, outcome auditOutcome, err error) report.Report {
+	r := outcome.Report
+	r.Application = config.Application
+	r.Stage = config.Stage
+	r.Path = config.Path
+	r.Entries = outcome.Submitted
+	r.Audited = outcome.Audited
+	if r.Outcome == "" {
+		r.Outcome = report.OutcomePass
+	}
+
+	switch {
+	case err != nil:
+		r.Outcome = report.OutcomeError
+		r.Error = err.Error()
+	case outcome.Skipped:
+		r.Outcome = report.OutcomeSkipped
+	}
+
+	return r
+}
+
+func printAuditResult(w io.Writer, r report.Report) (exitCode int, err error) {
+	fmt.Fprintln(w)
+	exitCode = r.ExitCode()
+	switch r.Outcome {
+	case report.OutcomeError:
+		log.WithField("error", r.Error).Error("Audit ended in an error")
+		logger.PrintErrorAndLogLocation(errors.New(r.Error))
+		return
+	case report.OutcomeSkipped:
+		fmt.Fprintln(w, "No new or modified entries since the last run, nothing to audit")
+		return
+	case report.OutcomeFailure:
+		log.WithField("policy_action", r.PolicyAction).Trace("Nexus IQ Server policy evaluation returned a Failure Policy Action")
+		fmt.Fprintln(w, "Hi, Hashbrowns here, you have some policy violations to clean up!")
+		for _, v := range r.Violations {
+			fmt.Fprintf(w, "  [%s] %s  %s  %s\n", v.Severity, v.Sha1, v.Location, v.Reason)
+		}
+		if note := r.ViolationsNote(); note != "" {
+			fmt.Fprintln(w, note)
+		}
+		printWaived(w, r)
+		printReportURL(w, r)
+		return
+	}
+	log.WithField("policy_action", r.PolicyAction).Trace("Nexus IQ Server policy evaluation returned policy results")
+	if len(r.Waived) > 0 {
+		fmt.Fprintln(w, "Wonderbar! Every policy violation reported for this audit has been waived!")
+	} else {
+		fmt.Fprintln(w, "Wonderbar! No policy violations reported for this audit!")
+	}
+	printWaived(w, r)
+	printReportURL(w, r)
+	return
+}
+
+// printWaived lists the violations that waivers took out of the result, so accepted risks stay visible
+func printWaived(w io.Writer, r report.Report) {
+	if len(r.Waived) == 0 {
+		return
+	}
+	fmt.Fprintf(w, "Waived %d policy violation(s):\n", len(r.Waived))
+	for _, v := range r.Waived {
+		fmt.Fprintf(w, "  [%s] %s  %s  %s (waived: %s)\n", v.Severity, v.Sha1, v.Location, v.Reason, v.Waiver)
+	}
+}
 
-	if res.PolicyAction != "Failure" {
-		log.WithField("policy_action", res.PolicyAction).Trace("Nexus IQ Server policy evaluation returned policy results")
-		fmt.Println("Wonderbar! No policy violations reported for this audit!")
-		fmt.Println("Report URL: ", res.ReportHTMLURL)
-		return 0, nil
+// printReportURL prints where to see the full report, if the audit went somewhere that has one
+func printReportURL(w io.Writer, r report.Report) {
+	if r.ReportURL != "" {
+		fmt.Fprintln(w, "Report URL: ", r.ReportURL)
 	}
-	log.WithField("policy_action", res.PolicyAction).Trace("Nexus IQ Server policy evaluation returned a Failure Policy Action")
-	fmt.Println("Hi, Hashbrowns here, you have some policy violations to clean up!")
-	fmt.Println("Report URL: ", res.ReportHTMLURL)
-	return 1, nil
 }
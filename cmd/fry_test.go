@@ -16,11 +16,23 @@
 package cmd
 
 import (
+	"bytes"
 	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/jarcoal/httpmock"
+	"github.com/sonatype-nexus-community/go-sona-types/cyclonedx"
+	"github.com/sonatype-nexus-community/hashbrowns/backend"
+	"github.com/sonatype-nexus-community/hashbrowns/diff"
+	"github.com/sonatype-nexus-community/hashbrowns/report"
 	"github.com/sonatype-nexus-community/hashbrowns/types"
+	"github.com/sonatype-nexus-community/hashbrowns/waiver"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -60,7 +72,19 @@ func validateConfigFryError(t *testing.T, expectedErrorMsgSnippet string, expect
 	assert.Equal(t, expectedErrorMsgSnippet, err.Error())
 }
 
+func setupStdin(t *testing.T, input string, isPipe bool) {
+	origStdin, origStdinIsPipe := stdin, stdinIsPipe
+	t.Cleanup(func() {
+		stdin, stdinIsPipe = origStdin, origStdinIsPipe
+	})
+
+	stdin = strings.NewReader(input)
+	stdinIsPipe = func() bool { return isPipe }
+}
+
 func TestFryCommandConfigDefaultsMissingPath(t *testing.T) {
+	setupStdin(t, "", false)
+
 	validateConfigFryError(t,
 		"Path not set, see usage for more information",
 		types.Config{User: "admin", Token: "admin123", Server: "http://localhost:8070", Stage: "develop", MaxRetries: 300},
@@ -75,17 +99,30 @@ func TestFryCommandConfigDefaultsMissingApplication(t *testing.T) {
 }
 
 func TestFryCommandConfigNoServerRunning(t *testing.T) {
+	origConfig := config
+	t.Cleanup(func() {
+		config = origConfig
+	})
+
 	httpmock.Activate()
 	defer httpmock.DeactivateAndReset()
 
 	httpmock.RegisterResponder("GET", "http://sillyplace.com:8090/api/v2/applications?publicId=testapp",
 		httpmock.NewErrorResponder(fmt.Errorf("dial tcp sillyplace.com:8090: connect: connection refused")))
 
-	validateConfigFryError(t,
-		"Get \"http://sillyplace.com:8090/api/v2/applications?publicId=testapp\": dial tcp sillyplace.com:8090: connect: connection refused",
-		types.Config{User: "admin", Token: "admin123", Server: "http://sillyplace.com:8090", Stage: "develop", MaxRetries: 300,
-			Path: "testdata/emptyFile", Application: "testapp"},
-		"fry", "--path=testdata/emptyFile", "--application=testapp", "--server-url=http://sillyplace.com:8090")
+	dir, err := ioutil.TempDir("", "output")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	// An audit that can't be done exits 2 with an error report, the same as it would in a batch
+	_, err = executeCommand(rootCmd, "fry", "--path=testdata/emptyFile", "--application=testapp", "--server-url=http://sillyplace.com:8090",
+		"--output=json="+filepath.Join(dir, "result.json"), "--output=text")
+	assert.Equal(t, ExitError{Code: 2}, err)
+
+	result, err := ioutil.ReadFile(filepath.Join(dir, "result.json"))
+	assert.NoError(t, err)
+	assert.Contains(t, string(result), `"outcome": "error"`)
+	assert.Contains(t, string(result), `connect: connection refused`)
 }
 
 func TestFryCommandWithRunningIQ(t *testing.T) {
@@ -104,3 +141,377 @@ func TestFryCommandWithRunningIQ(t *testing.T) {
 	_, err := executeCommand(rootCmd, "fry", "--path=testdata/emptyFile", "--application=testapp", "--server-url=http://sillyplace.com:8090")
 	assert.Nil(t, err)
 }
+
+func TestFryCommandReadsStdin(t *testing.T) {
+	setupStdin(t, "9987ca4f73d5ea0e534dfbf19238552df4de507e  main.go\n", true)
+
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+
+	httpmock.RegisterResponder("GET", "http://sillyplace.com:8090/api/v2/applications?publicId=testapp",
+		httpmock.NewStringResponder(200, applicationsResponse))
+
+	var submitted string
+	httpmock.RegisterResponder("POST", "http://sillyplace.com:8090/api/v2/scan/applications/4bb67dcfc86344e3a483832f8c496419/sources/nancy?stageId=develop",
+		func(req *http.Request) (*http.Response, error) {
+			body, _ := ioutil.ReadAll(req.Body)
+			submitted = string(body)
+			return httpmock.NewStringResponse(202, thirdPartyAPIResultJSON), nil
+		})
+
+	httpmock.RegisterResponder("GET", "http://sillyplace.com:8090/api/v2/scan/applications/4bb67dcfc86344e3a483832f8c496419/status/9cee2b6366fc4d328edc318eae46b2cb",
+		httpmock.NewStringResponder(200, pollingResult))
+
+	_, err := executeCommand(rootCmd, "fry", "--path=-", "--application=testapp", "--server-url=http://sillyplace.com:8090")
+	assert.Nil(t, err)
+	assert.Contains(t, submitted, "9987ca4f73d5ea0e534dfbf19238552df4de507e")
+}
+
+func TestFryCommandNewOnlyNothingChanged(t *testing.T) {
+	origConfig := config
+	t.Cleanup(func() {
+		config = origConfig
+	})
+
+	dir, err := ioutil.TempDir("", "state")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+
+	_, err = executeCommand(rootCmd, "fry", "--path=testdata/emptyFile", "--application=testapp", "--server-url=http://sillyplace.com:8090",
+		"--new-only", "--state-dir="+dir)
+	assert.Nil(t, err)
+	assert.Equal(t, 0, httpmock.GetTotalCallCount())
+
+	_, err = os.Stat(filepath.Join(dir, "testapp", "develop.json"))
+	assert.Nil(t, err)
+}
+
+func TestFryCommandNewOnlyAuditsFailuresAgain(t *testing.T) {
+	origConfig := config
+	t.Cleanup(func() {
+		config = origConfig
+	})
+
+	dir, err := ioutil.TempDir("", "state")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	args := []string{"fry", "--path=testdata/before.txt", "--application=testapp", "--deny-list=testdata/deny.txt",
+		"--new-only", "--state-dir=" + dir}
+	_, err = executeCommand(rootCmd, args...)
+	assert.Equal(t, ExitError{Code: 1}, err)
+
+	_, err = executeCommand(rootCmd, args...)
+	assert.Equal(t, ExitError{Code: 1}, err)
+
+	saved, ok, err := diff.LoadState(dir, "testapp", "develop")
+	assert.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, 1, len(saved))
+	assert.Equal(t, "lib/bar.jar", saved[0].Location)
+}
+
+func TestFryCommandNewOnlyAuditsWaivedAgain(t *testing.T) {
+	origConfig := config
+	t.Cleanup(func() {
+		config = origConfig
+	})
+
+	dir, err := ioutil.TempDir("", "state")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	for _, mode := range []string{"submission", "violations"} {
+		_, err = executeCommand(rootCmd, "fry", "--path=testdata/before.txt", "--application=testapp", "--deny-list=testdata/deny.txt",
+			"--waivers=testdata/waivers.txt", "--waiver-mode="+mode, "--new-only", "--state-dir="+dir, "--stage="+mode)
+		assert.Nil(t, err)
+
+		saved, ok, err := diff.LoadState(dir, "testapp", mode)
+		assert.NoError(t, err)
+		assert.True(t, ok)
+		assert.Equal(t, 1, len(saved), mode)
+		assert.Equal(t, "lib/bar.jar", saved[0].Location, mode)
+	}
+}
+
+func TestFryCommandUnknownOutput(t *testing.T) {
+	origConfig := config
+	t.Cleanup(func() {
+		config = origConfig
+	})
+
+	validateConfigFryError(t,
+		"Unknown output \"xml\", supported outputs are: text, json, junit, sarif, html, markdown",
+		types.Config{},
+		"fry", "--path=testdata/emptyFile", "--application=testapp", "--output=xml")
+}
+
+func TestFryCommandDenyList(t *testing.T) {
+	origConfig := config
+	t.Cleanup(func() {
+		config = origConfig
+	})
+
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+
+	_, err := executeCommand(rootCmd, "fry", "--path=testdata/before.txt", "--deny-list=testdata/deny.txt")
+	assert.Equal(t, ExitError{Code: 1}, err)
+	assert.Equal(t, 0, httpmock.GetTotalCallCount())
+}
+
+func TestFryCommandMockBackend(t *testing.T) {
+	origConfig := config
+	t.Cleanup(func() {
+		config = origConfig
+	})
+
+	_, err := executeCommand(rootCmd, "fry", "--path=testdata/before.txt", "--backend=mock", "--mock-outcome=failure")
+	assert.Equal(t, ExitError{Code: 1}, err)
+	assert.Equal(t, 1, len(auditor.(*backend.MockAuditor).Audited()))
+}
+
+func TestFryCommandWaivedViolationsPass(t *testing.T) {
+	origConfig := config
+	t.Cleanup(func() {
+		config = origConfig
+	})
+
+	_, err := executeCommand(rootCmd, "fry", "--path=testdata/before.txt", "--deny-list=testdata/deny.txt", "--waivers=testdata/waivers.txt")
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(waivers.Expired))
+}
+
+func TestApplyWaiversUnconfirmedViolations(t *testing.T) {
+	origWaivers := waivers
+	t.Cleanup(func() {
+		waivers = origWaivers
+	})
+	var err error
+	waivers, err = waiver.Load("testdata/waivers.txt", time.Now())
+	assert.NoError(t, err)
+
+	// Waiving the threat level 9 warning leaves the threat level 5 violation, which could be what failed the audit
+	r := applyWaivers(&types.Config{WaiverMode: waiverModeViolations}, report.Report{
+		Outcome: report.OutcomeFailure,
+		Violations: []report.Violation{
+			{Sha1: "9987ca4f73d5ea0e534d", Location: "build-1/lib/foo.jar", Reason: "Security-Warn", ThreatLevel: 9},
+			{Sha1: "2a72a07fbc9de22308d1", Location: "lib/bar.jar", Reason: "License-Fail", ThreatLevel: 5},
+		},
+		ViolationsUnconfirmed: true,
+	})
+
+	assert.Equal(t, report.OutcomeFailure, r.Outcome)
+	assert.Equal(t, 1, len(r.Waived))
+	assert.Equal(t, "License-Fail", r.Violations[0].Reason)
+}
+
+func TestFryCommandWaiverModeSubmission(t *testing.T) {
+	origConfig := config
+	t.Cleanup(func() {
+		config = origConfig
+	})
+
+	_, err := executeCommand(rootCmd, "fry", "--path=testdata/before.txt", "--backend=mock", "--mock-outcome=failure",
+		"--waivers=testdata/waivers.txt", "--waiver-mode=submission")
+	assert.Equal(t, ExitError{Code: 1}, err)
+	audited := auditor.(*backend.MockAuditor).Audited()
+	assert.Equal(t, 1, len(audited))
+	assert.Equal(t, 1, len(audited[0]))
+	assert.Equal(t, "lib/bar.jar", audited[0][0].Location)
+}
+
+func TestFryCommandMissingWaivers(t *testing.T) {
+	origConfig := config
+	t.Cleanup(func() {
+		config = origConfig
+	})
+
+	_, err := executeCommand(rootCmd, "fry", "--path=testdata/before.txt", "--backend=mock", "--waivers=testdata/missing.txt")
+	assert.Error(t, err)
+}
+
+func TestFryCommandRewritesLocations(t *testing.T) {
+	origConfig := config
+	t.Cleanup(func() {
+		config = origConfig
+	})
+
+	_, err := executeCommand(rootCmd, "fry", "--path=testdata/before.txt", "--backend=mock",
+		"--exclude=removed.jar", "--strip-prefix=build-1/", "--path-rewrite=^lib/=vendor/")
+	assert.Nil(t, err)
+	audited := auditor.(*backend.MockAuditor).Audited()
+	assert.Equal(t, 1, len(audited))
+	assert.Equal(t, []string{"vendor/foo.jar", "vendor/bar.jar"}, []string{audited[0][0].Location, audited[0][1].Location})
+}
+
+func TestFryCommandRedactsAfterWaiversAndDiff(t *testing.T) {
+	origConfig := config
+	t.Cleanup(func() {
+		config = origConfig
+	})
+
+	dir, err := ioutil.TempDir("", "state")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	_, err = executeCommand(rootCmd, "fry", "--path=testdata/before.txt", "--application=testapp", "--backend=mock",
+		"--waivers=testdata/waivers.txt", "--waiver-mode=submission", "--redact-paths=hash", "--new-only", "--state-dir="+dir)
+	assert.Nil(t, err)
+
+	// lib/removed.jar is waived by its path, and the rest are submitted with their locations hashed
+	audited := auditor.(*backend.MockAuditor).Audited()
+	assert.Equal(t, 1, len(audited))
+	assert.Equal(t, 1, len(audited[0]))
+	assert.Equal(t, "2a72a07fbc9de22308d12a32f7d33504349e63c9", audited[0][0].Sha1)
+	assert.NotEqual(t, "lib/bar.jar", audited[0][0].Location)
+
+	saved, _, err := diff.LoadState(dir, "testapp", "develop")
+	assert.NoError(t, err)
+	assert.Equal(t, 1, len(saved))
+	assert.Equal(t, "lib/bar.jar", saved[0].Location)
+}
+
+func TestFryCommandRedactedGlobWaiver(t *testing.T) {
+	origConfig := config
+	t.Cleanup(func() {
+		config = origConfig
+	})
+
+	validateConfigFryError(t,
+		`Waiver "lib/removed.jar" on line 3 of testdata/waivers.txt can't match locations redacted with --redact-paths hash, waive the sha1 instead, or use --waiver-mode submission`,
+		types.Config{},
+		"fry", "--path=testdata/before.txt", "--backend=mock", "--waivers=testdata/waivers.txt", "--waiver-mode=violations", "--redact-paths=hash")
+}
+
+func TestFryCommandJUnitOutputFile(t *testing.T) {
+	origConfig := config
+	t.Cleanup(func() {
+		config = origConfig
+	})
+
+	dir, err := ioutil.TempDir("", "output")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+	outputFile := filepath.Join(dir, "results.xml")
+
+	_, err = executeCommand(rootCmd, "fry", "--path=testdata/before.txt", "--application=testapp", "--deny-list=testdata/deny.txt",
+		"--output=junit", "--output-file="+outputFile)
+	assert.Equal(t, ExitError{Code: 1}, err)
+
+	results, err := ioutil.ReadFile(outputFile)
+	assert.NoError(t, err)
+	assert.Contains(t, string(results), `<testsuite name="testapp (develop)" tests="3" failures="3" errors="0" skipped="0">`)
+	assert.Contains(t, string(results), `name="build-1/lib/foo.jar (9987ca4f73d5ea0e534dfbf19238552df4de507e)"`)
+}
+
+func TestFryCommandMultipleOutputs(t *testing.T) {
+	origConfig := config
+	t.Cleanup(func() {
+		config = origConfig
+	})
+
+	dir, err := ioutil.TempDir("", "output")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	_, err = executeCommand(rootCmd, "fry", "--path=testdata/before.txt", "--application=testapp", "--deny-list=testdata/deny.txt",
+		"--output=json="+filepath.Join(dir, "result.json"), "--output=junit="+filepath.Join(dir, "junit.xml"), "--output=text")
+	assert.Equal(t, ExitError{Code: 1}, err)
+
+	result, err := ioutil.ReadFile(filepath.Join(dir, "result.json"))
+	assert.NoError(t, err)
+	assert.Contains(t, string(result), `"outcome": "failure"`)
+
+	junit, err := ioutil.ReadFile(filepath.Join(dir, "junit.xml"))
+	assert.NoError(t, err)
+	assert.Contains(t, string(junit), `<testsuite name="testapp (develop)"`)
+}
+
+func TestFryCommandDryRun(t *testing.T) {
+	origConfig := config
+	t.Cleanup(func() {
+		config = origConfig
+	})
+
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+
+	httpmock.RegisterResponder("GET", "http://sillyplace.com:8090/api/v2/applications?publicId=testapp",
+		httpmock.NewStringResponder(200, applicationsResponse))
+
+	_, err := executeCommand(rootCmd, "fry", "--path=testdata/before.txt", "--application=testapp", "--server-url=http://sillyplace.com:8090",
+		"--dry-run", "--resolve-application")
+	assert.Nil(t, err)
+	assert.Equal(t, map[string]int{"GET http://sillyplace.com:8090/api/v2/applications?publicId=testapp": 1}, httpmock.GetCallCountInfo())
+}
+
+func TestFryCommandDryRunOtherBackend(t *testing.T) {
+	origConfig := config
+	t.Cleanup(func() {
+		config = origConfig
+	})
+
+	validateConfigFryError(t,
+		"Dry runs show what would be submitted to Nexus IQ Server, so only apply to the iq backend",
+		types.Config{},
+		"fry", "--path=testdata/before.txt", "--deny-list=testdata/deny.txt", "--dry-run")
+}
+
+func TestPrintDryRun(t *testing.T) {
+	var submit []cyclonedx.Sha1SBOM
+	for i := 0; i < 12; i++ {
+		submit = append(submit, cyclonedx.Sha1SBOM{Sha1: "9987ca4f73d5ea0e534dfbf19238552df4de507e", Location: fmt.Sprintf("lib/%d.jar", i)})
+	}
+
+	var b bytes.Buffer
+	printDryRun(&b, dryRun{
+		URL:         "http://localhost:8070/api/v2/scan/applications/4bb67dcfc86344e3a483832f8c496419/sources/nancy?stageId=build",
+		Application: "testapp",
+		InternalID:  "4bb67dcfc86344e3a483832f8c496419",
+		Stage:       "build",
+		Entries:     15,
+		Submit:      submit,
+		SBOMSize:    45200,
+	})
+
+	assert.Equal(t, `Dry run, nothing was submitted to Nexus IQ Server
+Target URL:   http://localhost:8070/api/v2/scan/applications/4bb67dcfc86344e3a483832f8c496419/sources/nancy?stageId=build
+Application:  testapp (internal ID 4bb67dcfc86344e3a483832f8c496419)
+Stage:        build
+Components:   12 of 15 entries
+SBOM size:    45.2 kB
+
+Sample of 10 entries:
+  9987ca4f73d5ea0e534dfbf19238552df4de507e  lib/0.jar
+  9987ca4f73d5ea0e534dfbf19238552df4de507e  lib/1.jar
+  9987ca4f73d5ea0e534dfbf19238552df4de507e  lib/2.jar
+  9987ca4f73d5ea0e534dfbf19238552df4de507e  lib/3.jar
+  9987ca4f73d5ea0e534dfbf19238552df4de507e  lib/4.jar
+  9987ca4f73d5ea0e534dfbf19238552df4de507e  lib/5.jar
+  9987ca4f73d5ea0e534dfbf19238552df4de507e  lib/6.jar
+  9987ca4f73d5ea0e534dfbf19238552df4de507e  lib/7.jar
+  9987ca4f73d5ea0e534dfbf19238552df4de507e  lib/8.jar
+  9987ca4f73d5ea0e534dfbf19238552df4de507e  lib/9.jar
+  ... and 2 more
+`, b.String())
+}
+
+func TestParseOutputs(t *testing.T) {
+	sinks, err := parseOutputs(&types.Config{})
+	assert.NoError(t, err)
+	assert.Equal(t, []outputSink{{Format: outputText}}, sinks)
+
+	sinks, err = parseOutputs(&types.Config{Outputs: []string{"junit", "json=-", "html=report.html"}, OutputFile: "junit.xml"})
+	assert.NoError(t, err)
+	assert.Equal(t, []outputSink{{Format: outputJUnit, Path: "junit.xml"}, {Format: outputJSON}, {Format: outputHTML, Path: "report.html"}}, sinks)
+
+	_, err = parseOutputs(&types.Config{Outputs: []string{"json", "text"}})
+	assert.EqualError(t, err, "Only one output can be written to stdout, write the others to files with --output format=path")
+
+	_, err = parseOutputs(&types.Config{Outputs: []string{"json=out", "text=out"}})
+	assert.EqualError(t, err, "Only one output can be written to out")
+}
@@ -0,0 +1,136 @@
+//
+// Copyright © 2020-present Sonatype Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/sonatype-nexus-community/go-sona-types/cyclonedx"
+	"github.com/sonatype-nexus-community/hashbrowns/filter"
+	"github.com/sonatype-nexus-community/hashbrowns/image"
+	"github.com/sonatype-nexus-community/hashbrowns/parse"
+	"github.com/sonatype-nexus-community/hashbrowns/types"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+// imageCmd represents the image command
+var imageCmd = &cobra.Command{
+	Use:   "image",
+	Short: "Submit sha1s of the files in a saved container image to Nexus IQ Server",
+	Long: `Provided a path to a tarball created by docker save (or an OCI image layout tarball), this command will hash the
+files in each layer of the image, and submit them to Nexus IQ Server.
+
+Whiteouts are honoured, so only files in the final image filesystem are audited. Locations are prefixed with the
+digest of the layer each file came from.`,
+	SilenceErrors: true,
+	RunE: func(cmd *cobra.Command, args []string) (err error) {
+		defer recoverAndPrintError(&err)
+
+		checkBackendFlags(cmd.Flags())
+		checkRequiredImageFlags(cmd.Flags())
+
+		setupLogger(&config)
+
+		log.Info("Running Image Command")
+
+		if auditor, err = newAuditor(&config); err != nil {
+			panic(err)
+		}
+
+		if err = loadWaivers(&config); err != nil {
+			panic(err)
+		}
+
+		sha1s, err := doHashImage(&config)
+		if err != nil {
+			panic(err)
+		}
+
+		// Sizes come from the tarball while hashing, so only names are filtered again, to catch nested entries
+		nameOnly := config
+		nameOnly.MinSize, nameOnly.MaxSize = 0, 0
+		if sha1s, err = doFilter(&nameOnly, progressWriter(), sha1s); err != nil {
+			panic(err)
+		}
+		if sha1s, err = doRewriteLocations(&config, progressWriter(), sha1s); err != nil {
+			panic(err)
+		}
+
+		submit := doWaiveEntries(&config, progressWriter(), sha1s)
+		submit = doRedactLocations(&config, submit)
+		r, auditErr := auditor.Audit(submit, config.Application, config.Stage)
+
+		outcome := auditOutcome{Entries: len(sha1s), Submitted: len(submit), Audited: submit, Report: applyWaivers(&config, r)}
+		var exitCode int
+		if exitCode, err = printAuditResult(os.Stdout, newReport(&config, outcome, auditErr)); err != nil {
+			panic(err)
+		}
+
+		if exitCode == 0 {
+			return
+		}
+
+		return ExitError{Code: exitCode}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(imageCmd)
+
+	pf := imageCmd.PersistentFlags()
+
+	pf.StringVar(&config.ImageTar, "tar", "", "Path to image tarball created by docker save (required)")
+	pf.IntVar(&config.ArchiveDepth, "archive-depth", 0, "Specify how many levels of nested jar, war, zip and tar archives to hash inside of")
+	pf.BoolVar(&config.LibrariesOnly, "libraries-only", false, fmt.Sprintf("Only hash files that look like libraries, with any of: %s", strings.Join(filter.LibraryExtensions, ", ")))
+	addFilterFlags(pf)
+	addLocationFlags(pf)
+	addIQFlags(pf)
+	addApplicationFlag(pf)
+	addBackendFlags(pf)
+	addWaiverFlags(pf)
+}
+
+func checkRequiredImageFlags(flags *pflag.FlagSet) {
+	if !flags.Changed("tar") {
+		panic(fmt.Errorf("Image tarball not set, see usage for more information"))
+	}
+	checkApplicationFlag(flags)
+}
+
+func doHashImage(config *types.Config) (sha1s []cyclonedx.Sha1SBOM, err error) {
+	opts := filterOptions(config)
+	if err = opts.Validate(); err != nil {
+		return
+	}
+
+	log.WithField("tar", config.ImageTar).Info("Beginning hashing of image tarball")
+	sha1s, err = image.Tarball(config.ImageTar, image.Options{
+		ArchiveDepth: config.ArchiveDepth,
+		Include:      opts.Matches,
+	})
+	if err != nil {
+		log.WithField("error", err).Error("Error hashing image tarball")
+
+		return
+	}
+	sha1s = parse.RemoveDuplicates(sha1s)
+	log.WithField("entries", len(sha1s)).Debug("Obtained sha1 struct from image tarball")
+
+	return
+}
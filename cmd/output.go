@@ -0,0 +1,153 @@
+//
+// Copyright © 2020-present Sonatype Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/sonatype-nexus-community/hashbrowns/report"
+	"github.com/sonatype-nexus-community/hashbrowns/types"
+)
+
+const (
+	outputText     = "text"
+	outputJSON     = "json"
+	outputJUnit    = "junit"
+	outputSARIF    = "sarif"
+	outputHTML     = "html"
+	outputMarkdown = "markdown"
+)
+
+// outputSink is one --output, the format to write and the file to write it to, or stdout if path is empty
+type outputSink struct {
+	Format string
+	Path   string
+}
+
+func outputs() []string {
+	return []string{outputText, outputJSON, outputJUnit, outputSARIF, outputHTML, outputMarkdown}
+}
+
+func isOutput(name string) bool {
+	for _, v := range outputs() {
+		if v == name {
+			return true
+		}
+	}
+	return false
+}
+
+// parseOutputs turns each --output, a format or format=path, into a sink. Outputs without a path go to --output-file
+// if it is set, or stdout otherwise, and only one output can go to each.
+func parseOutputs(config *types.Config) (sinks []outputSink, err error) {
+	values := config.Outputs
+	if len(values) == 0 {
+		values = []string{outputText}
+	}
+
+	paths := map[string]bool{}
+	for _, v := range values {
+		parts := strings.SplitN(v, "=", 2)
+		sink := outputSink{Format: parts[0], Path: config.OutputFile}
+		if len(parts) == 2 {
+			sink.Path = parts[1]
+		}
+		if sink.Path == "-" {
+			sink.Path = ""
+		}
+
+		if !isOutput(sink.Format) {
+			return nil, fmt.Errorf("Unknown output %q, supported outputs are: %s", sink.Format, strings.Join(outputs(), ", "))
+		}
+		if paths[sink.Path] {
+			if sink.Path == "" {
+				return nil, fmt.Errorf("Only one output can be written to stdout, write the others to files with --output format=path")
+			}
+			return nil, fmt.Errorf("Only one output can be written to %s", sink.Path)
+		}
+		paths[sink.Path] = true
+		sinks = append(sinks, sink)
+	}
+
+	log.WithField("outputs", sinks).Debug("Parsed outputs")
+
+	return
+}
+
+// writeOutputs writes reports to every sink, and returns the exit code they add up to. A sink that can't be written
+// doesn't stop the rest from being written, the first error is returned once they have all been tried.
+func writeOutputs(config *types.Config, sinks []outputSink, reports []report.Report) (exitCode int, err error) {
+	for _, v := range sinks {
+		if sinkErr := writeOutput(config, v, reports); sinkErr != nil && err == nil {
+			err = sinkErr
+		}
+	}
+	return worstExitCode(reports), err
+}
+
+func writeOutput(config *types.Config, sink outputSink, reports []report.Report) (err error) {
+	if sink.Path == "" {
+		return writeReports(os.Stdout, config, sink.Format, reports)
+	}
+
+	log.WithFields(logrus.Fields{
+		"output": sink.Format,
+		"path":   sink.Path,
+	}).Info("Writing output to file")
+	f, err := os.Create(sink.Path)
+	if err != nil {
+		return
+	}
+	defer func() {
+		if closeErr := f.Close(); err == nil {
+			err = closeErr
+		}
+	}()
+
+	return writeReports(f, config, sink.Format, reports)
+}
+
+// writeReports writes reports to w in format
+func writeReports(w io.Writer, config *types.Config, format string, reports []report.Report) (err error) {
+	batch := config.Manifest != ""
+	switch format {
+	case outputJSON:
+		if batch {
+			return report.WriteJSON(w, reports)
+		}
+		return reports[0].WriteJSON(w)
+	case outputJUnit:
+		return report.WriteJUnit(w, reports)
+	case outputSARIF:
+		return report.WriteSARIF(w, reports)
+	case outputHTML:
+		return report.WriteHTML(w, reports, time.Now())
+	case outputMarkdown:
+		return report.WriteMarkdown(w, reports)
+	}
+
+	if !batch {
+		_, err = printAuditResult(w, reports[0])
+		return
+	}
+	fmt.Fprintln(w)
+	return writeBatchSummary(w, reports)
+}
@@ -19,6 +19,7 @@ import (
 	"fmt"
 	"os"
 
+	"github.com/sonatype-nexus-community/hashbrowns/logger"
 	"github.com/sonatype-nexus-community/hashbrowns/types"
 	"github.com/spf13/cobra"
 
@@ -47,9 +48,32 @@ func init() {
 	cobra.OnInitialize(initConfig)
 
 	rootCmd.PersistentFlags().CountVarP(&config.LogLevel, "", "v", "Set log level, higher is more verbose")
+	rootCmd.PersistentFlags().BoolVarP(&config.Quiet, "quiet", "q", false, "Only print results and errors, without the banner or any progress")
+	rootCmd.PersistentFlags().BoolVar(&config.NoBanner, "no-banner", false, "Don't print the banner")
+	rootCmd.PersistentFlags().StringVar(&config.LogFile, "log-file", "", "File to write logs to, or - for stderr (default ~/"+types.HashbrownsDirName+"/hashbrowns.combined.log)")
+	rootCmd.PersistentFlags().StringVar(&config.LogFormat, "log-format", logger.FormatJSON, fmt.Sprintf("Specify format of logs, one of: %s, %s", logger.FormatJSON, logger.FormatText))
+	rootCmd.PersistentFlags().BoolVar(&config.LogAppend, "log-append", false, "Append to the log file, instead of starting it over every run")
+	rootCmd.PersistentFlags().BoolVar(&config.TracePayloads, "trace-payloads", false, "Log SBOMs and responses from Nexus IQ Server in full, instead of just their size")
+}
+
+// setupLogger sets up the logger as the log flags ask, so every run writes its logs where it was told to
+func setupLogger(config *types.Config) {
+	if err := logger.Setup(logger.Options{
+		File:   config.LogFile,
+		Format: config.LogFormat,
+		Append: config.LogAppend,
+		Level:  config.LogLevel,
+		// The token is used as the password for Nexus IQ Server, so is never logged
+		Secrets:       []string{config.Token},
+		TracePayloads: config.TracePayloads,
+	}); err != nil {
+		panic(err)
+	}
 }
 
 func initConfig() {
+	setupDecorations()
+
 	if cfgFile != "" {
 		viper.SetConfigFile(cfgFile)
 	} else {
@@ -66,6 +90,6 @@ func initConfig() {
 	viper.AutomaticEnv()
 
 	if err := viper.ReadInConfig(); err == nil {
-		fmt.Println("Using config file:", viper.ConfigFileUsed())
+		fmt.Fprintln(progressWriter(), "Using config file:", viper.ConfigFileUsed())
 	}
 }
@@ -90,3 +90,24 @@ func TestRootCommandLogVerbosity(t *testing.T) {
 	validateConfigLogging(t, "", types.Config{LogLevel: 2}, "-vv")
 	validateConfigLogging(t, "", types.Config{LogLevel: 3}, "-vvv")
 }
+
+func TestRootCommandDecorations(t *testing.T) {
+	origStdoutIsTerminal, origPrintBanner := stdoutIsTerminal, printBanner
+	t.Cleanup(func() {
+		stdoutIsTerminal, printBanner = origStdoutIsTerminal, origPrintBanner
+	})
+	var banners int
+	printBanner = func() { banners++ }
+
+	stdoutIsTerminal = func() bool { return true }
+	validateConfigLogging(t, "", types.Config{}, "")
+	assert.Equal(t, 1, banners)
+
+	validateConfigLogging(t, "", types.Config{NoBanner: true}, "--no-banner")
+	validateConfigLogging(t, "", types.Config{Quiet: true}, "--quiet")
+	assert.Equal(t, 1, banners)
+
+	stdoutIsTerminal = func() bool { return false }
+	validateConfigLogging(t, "", types.Config{}, "")
+	assert.Equal(t, 1, banners)
+}
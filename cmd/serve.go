@@ -0,0 +1,136 @@
+//
+// Copyright © 2020-present Sonatype Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/sonatype-nexus-community/go-sona-types/cyclonedx"
+	"github.com/sonatype-nexus-community/hashbrowns/progress"
+	"github.com/sonatype-nexus-community/hashbrowns/report"
+	"github.com/sonatype-nexus-community/hashbrowns/server"
+	"github.com/spf13/cobra"
+)
+
+// shutdownTimeout is how long requests in flight get to finish once the server is asked to stop
+const shutdownTimeout = 10 * time.Second
+
+// serveCmd represents the serve command
+var serveCmd = &cobra.Command{
+	Use:   "serve",
+	Short: "Run an HTTP API that submits lists of sha1s to Nexus IQ Server",
+	Long: `This command runs a small REST API, so other services can ask whether a set of hashes is OK without running
+hashbrowns themselves.
+
+POST a list of sha1s (or an SBOM, with ?format=) to ` + server.AuditsPath + `?application=<id>&stage=<stage> to queue a job,
+then GET ` + server.AuditsPath + `/<job id> until its status is done. The result is the same as fry --output json.`,
+	SilenceErrors: true,
+	RunE: func(cmd *cobra.Command, args []string) (err error) {
+		defer recoverAndPrintError(&err)
+
+		checkBackendFlags(cmd.Flags())
+
+		setupLogger(&config)
+
+		log.Info("Running Serve Command")
+
+		// Jobs are audited at the same time, with nobody watching their progress
+		progress.Setup(ioutil.Discard, false)
+
+		if auditor, err = newAuditor(&config); err != nil {
+			panic(err)
+		}
+
+		if err = loadWaivers(&config); err != nil {
+			panic(err)
+		}
+
+		s := server.New(serveAudit, server.Options{
+			Concurrency:     config.Concurrency,
+			QueueSize:       config.QueueSize,
+			MaxBodySize:     config.MaxBodySize,
+			MaxFinishedJobs: config.MaxFinishedJobs,
+			DefaultStage:    config.Stage,
+		})
+		defer s.Close()
+
+		httpServer := &http.Server{Addr: config.Listen, Handler: s}
+		failed := make(chan error, 1)
+		go func() {
+			failed <- httpServer.ListenAndServe()
+		}()
+
+		signals := make(chan os.Signal, 1)
+		signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+		defer signal.Stop(signals)
+
+		log.WithFields(logrus.Fields{
+			"listen":      config.Listen,
+			"concurrency": config.Concurrency,
+			"queue_size":  config.QueueSize,
+		}).Info("Serving audits API")
+		fmt.Printf("Serving audits API on http://%s%s, press Ctrl+C to stop\n", config.Listen, server.AuditsPath)
+
+		select {
+		case err = <-failed:
+			panic(err)
+		case <-signals:
+		}
+
+		log.Info("Shutting down audits API")
+		ctx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+		defer cancel()
+		if err = httpServer.Shutdown(ctx); err != nil {
+			panic(err)
+		}
+
+		return
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(serveCmd)
+
+	pf := serveCmd.PersistentFlags()
+
+	pf.StringVar(&config.Listen, "listen", "localhost:8080", "Address to serve the audits API on")
+	addIQFlags(pf)
+	addBackendFlags(pf)
+	addWaiverFlags(pf)
+	pf.IntVar(&config.Concurrency, "concurrency", 4, "Specify how many jobs to audit at the same time")
+	pf.IntVar(&config.QueueSize, "queue-size", 100, "Specify how many jobs can wait to be audited before new ones are turned away")
+	pf.Int64Var(&config.MaxBodySize, "max-body-size", 64<<20, "Largest list of sha1s or SBOM accepted, in bytes")
+	pf.IntVar(&config.MaxFinishedJobs, "max-finished-jobs", 1000, "Specify how many finished jobs to keep the results of, the oldest are forgotten first")
+}
+
+// serveAudit submits the sha1s of a job to Nexus IQ Server, with the rest of the config coming from the flags
+func serveAudit(sha1s []cyclonedx.Sha1SBOM, application string, stage string) report.Report {
+	c := config
+	c.Application = application
+	c.Stage = stage
+
+	submit := doWaiveEntries(&c, ioutil.Discard, sha1s)
+	r, err := auditor.Audit(submit, application, stage)
+	return newReport(&c, auditOutcome{Entries: len(sha1s), Submitted: len(submit), Report: applyWaivers(&c, r)}, err)
+}
@@ -0,0 +1,167 @@
+//
+// Copyright © 2020-present Sonatype Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/sonatype-nexus-community/go-sona-types/cyclonedx"
+	"github.com/sonatype-nexus-community/hashbrowns/location"
+	"github.com/sonatype-nexus-community/hashbrowns/report"
+	"github.com/sonatype-nexus-community/hashbrowns/types"
+	"github.com/sonatype-nexus-community/hashbrowns/waiver"
+	"github.com/spf13/pflag"
+)
+
+const (
+	waiverModeViolations = "violations"
+	waiverModeSubmission = "submission"
+)
+
+// waivers is set when there is a waiver file, nil otherwise
+var waivers *waiver.List
+
+// addWaiverFlags adds the flags for accepting known risks, for any command that audits
+func addWaiverFlags(pf *pflag.FlagSet) {
+	pf.StringVar(&config.WaiversFile, "waivers", "", fmt.Sprintf("File of accepted sha1s and path globs, with expiry dates and justifications (default %q, if it exists)", waiver.DefaultFile))
+	pf.StringVar(&config.WaiverMode, "waiver-mode", waiverModeViolations, "How waivers apply, violations: waived entries can't fail the audit, submission: waived entries aren't submitted at all")
+}
+
+// loadWaivers reads the waiver file, and warns loudly about any waivers that have expired
+func loadWaivers(config *types.Config) (err error) {
+	if config.WaiverMode != waiverModeViolations && config.WaiverMode != waiverModeSubmission {
+		return fmt.Errorf("Unknown waiver mode %q, supported modes are: %s, %s", config.WaiverMode, waiverModeViolations, waiverModeSubmission)
+	}
+
+	path := config.WaiversFile
+	if path == "" {
+		if _, err := os.Stat(waiver.DefaultFile); err != nil {
+			waivers = nil
+			return nil
+		}
+		path = waiver.DefaultFile
+	}
+
+	log.WithField("waivers", path).Info("Loading waivers")
+	if waivers, err = waiver.Load(path, time.Now()); err != nil {
+		log.WithField("error", err).Error("Error loading waivers")
+
+		return
+	}
+	log.WithFields(logrus.Fields{
+		"active":  len(waivers.Active),
+		"expired": len(waivers.Expired),
+	}).Debug("Loaded waivers")
+
+	if len(waivers.Expired) > 0 {
+		warnOfExpiredWaivers(waivers)
+	}
+
+	return checkWaiverGlobs(config, waivers)
+}
+
+// checkWaiverGlobs returns an error for path glob waivers that could never match, as waived violations are matched
+// against the locations that were submitted, after --redact-paths has changed them
+func checkWaiverGlobs(config *types.Config, list *waiver.List) error {
+	if config.WaiverMode != waiverModeViolations {
+		return nil
+	}
+
+	for _, w := range list.Active {
+		if w.Glob == "" {
+			continue
+		}
+		if config.RedactPaths == location.RedactHash || (config.RedactPaths == location.RedactBasename && strings.Contains(w.Glob, "/")) {
+			return fmt.Errorf("Waiver %q on line %d of %s can't match locations redacted with --redact-paths %s, waive the sha1 instead, or use --waiver-mode %s",
+				w.Glob, w.Line, list.Path, config.RedactPaths, waiverModeSubmission)
+		}
+	}
+	return nil
+}
+
+func warnOfExpiredWaivers(list *waiver.List) {
+	fmt.Fprintln(os.Stderr)
+	fmt.Fprintln(os.Stderr, "!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!")
+	fmt.Fprintf(os.Stderr, "!!!! WARNING : %d waiver(s) in %s have expired, and no longer apply\n", len(list.Expired), list.Path)
+	for _, w := range list.Expired {
+		fmt.Fprintf(os.Stderr, "!!!!   line %d: %s\n", w.Line, w)
+	}
+	fmt.Fprintln(os.Stderr, "!!!! Fix what they waived, or renew them with a new expiry date.")
+	fmt.Fprintln(os.Stderr, "!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!")
+	fmt.Fprintln(os.Stderr)
+}
+
+// doWaiveEntries leaves out waived entries before they are submitted, when that is how waivers apply
+func doWaiveEntries(config *types.Config, out io.Writer, sha1s []cyclonedx.Sha1SBOM) []cyclonedx.Sha1SBOM {
+	if waivers == nil || config.WaiverMode != waiverModeSubmission {
+		return sha1s
+	}
+
+	kept, waived := waivers.Filter(sha1s)
+	log.WithField("waived", len(waived)).Debug("Waived entries before submission")
+	if len(waived) > 0 {
+		fmt.Fprintf(out, "Waived %d of %d entries, not submitting them\n", len(waived), len(sha1s))
+	}
+	return kept
+}
+
+// unwaivedEntries leaves out the entries of sha1s that were waived, before submission or as violations in r, so they
+// are audited again once their waiver expires instead of being remembered as passing
+func unwaivedEntries(config *types.Config, sha1s []cyclonedx.Sha1SBOM, r report.Report) (kept []cyclonedx.Sha1SBOM) {
+	if waivers == nil {
+		return sha1s
+	}
+	if config.WaiverMode == waiverModeSubmission {
+		kept, _ = waivers.Filter(sha1s)
+		return
+	}
+
+	for _, v := range sha1s {
+		if !violates(v, r.Waived) {
+			kept = append(kept, v)
+		}
+	}
+	return
+}
+
+// applyWaivers takes waived violations out of a failed report, which passes if none are left. If the backend couldn't
+// say which violations failed the audit, any left could be the one that did, so it still fails.
+func applyWaivers(config *types.Config, r report.Report) report.Report {
+	if waivers == nil || config.WaiverMode != waiverModeViolations || r.Outcome != report.OutcomeFailure {
+		return r
+	}
+	if len(r.Violations) == 0 {
+		log.Warn("Unable to apply waivers, the backend did not say which entries failed")
+		fmt.Fprintln(os.Stderr, "Unable to apply waivers, the backend did not say which entries failed")
+		return r
+	}
+
+	r.Violations, r.Waived = waivers.ApplyToViolations(r.Violations)
+	log.WithField("waived", len(r.Waived)).Debug("Waived violations")
+	switch {
+	case len(r.Violations) == 0:
+		r.Outcome = report.OutcomePass
+	case len(r.Waived) > 0 && r.ViolationsUnconfirmed:
+		log.WithField("unwaived", len(r.Violations)).Warn("Unable to confirm waivers cover what failed the audit")
+		fmt.Fprintf(os.Stderr, "Waived %d policy violation(s), but %d that could have failed the audit are not waived, so it still fails\n", len(r.Waived), len(r.Violations))
+	}
+	return r
+}
@@ -0,0 +1,174 @@
+//
+// Copyright © 2020-present Sonatype Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/sonatype-nexus-community/hashbrowns/logger"
+	"github.com/sonatype-nexus-community/hashbrowns/parse"
+	"github.com/sonatype-nexus-community/hashbrowns/types"
+	"github.com/sonatype-nexus-community/hashbrowns/watch"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+// watchCmd represents the watch command
+var watchCmd = &cobra.Command{
+	Use:   "watch",
+	Short: "Submit sha1s to Nexus IQ Server every time a file or directory changes",
+	Long: `Provided a path to a file with sha1's and locations, or a directory to hash, this command will submit them to
+Nexus IQ Server, and then again every time the path changes, until it is stopped.
+
+Only changes in the policy outcome are printed, so this can be left running on build servers and artifact drop
+folders.`,
+	SilenceErrors: true,
+	RunE: func(cmd *cobra.Command, args []string) (err error) {
+		defer recoverAndPrintError(&err)
+
+		checkBackendFlags(cmd.Flags())
+		checkRequiredWatchFlags(cmd.Flags())
+
+		setupLogger(&config)
+
+		log.Info("Running Watch Command")
+
+		if auditor, err = newAuditor(&config); err != nil {
+			panic(err)
+		}
+
+		if err = loadWaivers(&config); err != nil {
+			panic(err)
+		}
+
+		if err = openHashCache(&config); err != nil {
+			panic(err)
+		}
+
+		stop := make(chan struct{})
+		signals := make(chan os.Signal, 1)
+		signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+		defer signal.Stop(signals)
+		go func() {
+			<-signals
+			close(stop)
+		}()
+
+		w := &watcher{config: &config, out: os.Stdout}
+		w.audit()
+
+		fmt.Printf("Watching %s for changes, press Ctrl+C to stop\n", config.Path)
+		opts := watch.Options{Debounce: config.Debounce, Exclude: watchExclude(&config)}
+		if err = watch.Path(config.Path, opts, stop, w.audit); err != nil {
+			panic(err)
+		}
+
+		return
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(watchCmd)
+
+	pf := watchCmd.PersistentFlags()
+
+	pf.StringVar(&config.Path, "path", "", "Path to file with sha1s, or directory to hash, to watch for changes (required)")
+	addIQFlags(pf)
+	addApplicationFlag(pf)
+	addBackendFlags(pf)
+	addWaiverFlags(pf)
+	pf.StringVar(&config.InputFormat, "input-format", parse.FormatShasum, fmt.Sprintf("Specify format of file at path, one of: %s", strings.Join(parse.Formats(), ", ")))
+	pf.StringVar(&config.CSVSha1Col, "csv-sha1-column", "sha1", "Specify CSV header name or zero based index of the sha1 column")
+	pf.StringVar(&config.CSVPathCol, "csv-path-column", "path", "Specify CSV header name or zero based index of the path column")
+	pf.IntVar(&config.ArchiveDepth, "archive-depth", 0, "Specify how many levels of nested jar, war, zip and tar archives to hash inside of when path is a directory")
+	pf.StringVar(&config.CacheDir, "cache-dir", "", "Directory to keep a cache of file hashes in, so unchanged files are not rehashed when path is a directory")
+	pf.BoolVar(&config.ClearCache, "clear-cache", false, "Discard any existing hash cache in --cache-dir before hashing the first time")
+	addFilterFlags(pf)
+	addLocationFlags(pf)
+	pf.DurationVar(&config.Debounce, "debounce", 2*time.Second, "How long changes have to settle for before submitting again")
+}
+
+func checkRequiredWatchFlags(flags *pflag.FlagSet) {
+	if !flags.Changed("path") || config.Path == stdinPath {
+		panic(fmt.Errorf("Path not set, see usage for more information"))
+	}
+	checkApplicationFlag(flags)
+}
+
+// watchExclude returns where hashbrowns writes its own files, which might be inside the watched path, so writing them
+// doesn't set off another audit
+func watchExclude(config *types.Config) (exclude []string) {
+	if config.CacheDir != "" {
+		exclude = append(exclude, config.CacheDir)
+	}
+	if config.Diff || config.NewOnly {
+		if dir, err := stateDir(config); err == nil {
+			exclude = append(exclude, dir)
+		}
+	}
+	if location := logger.LogFileLocation(); location != "" {
+		exclude = append(exclude, location)
+	}
+	return
+}
+
+// watcher runs an audit every time the watched path changes, and remembers the last outcome so it only has to print
+// when it changes
+type watcher struct {
+	config *types.Config
+	out    io.Writer
+	last   string
+}
+
+func (w *watcher) audit() {
+	log.WithField("path", w.config.Path).Info("Beginning audit of watched path")
+	outcome, err := doAudit(w.config, ioutil.Discard)
+
+	if hashCache != nil {
+		if err := hashCache.Save(); err != nil {
+			log.WithField("error", err).Error("Error saving hash cache")
+		}
+	}
+
+	w.report(time.Now(), outcome, err)
+}
+
+// report prints the outcome of an audit, if it is different to the last one
+func (w *watcher) report(now time.Time, outcome auditOutcome, err error) {
+	text := newReport(w.config, outcome, err).Summary()
+	if text == w.last {
+		log.WithField("outcome", text).Info("Policy outcome is unchanged")
+		return
+	}
+
+	timestamp := now.Format(time.RFC3339)
+	if w.last == "" {
+		fmt.Fprintf(w.out, "%s Policy outcome: %s\n", timestamp, text)
+	} else {
+		fmt.Fprintf(w.out, "%s Policy outcome changed from %s to %s\n", timestamp, w.last, text)
+	}
+	if outcome.Report.ReportURL != "" {
+		fmt.Fprintln(w.out, "Report URL: ", outcome.Report.ReportURL)
+	}
+	w.last = text
+}
@@ -0,0 +1,61 @@
+//
+// Copyright © 2020-present Sonatype Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+package cmd
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/sonatype-nexus-community/hashbrowns/report"
+	"github.com/sonatype-nexus-community/hashbrowns/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWatcherReportOnlyPrintsChanges(t *testing.T) {
+	out := new(bytes.Buffer)
+	w := &watcher{config: &types.Config{Application: "testapp"}, out: out}
+	now := time.Date(2020, 10, 1, 12, 0, 0, 0, time.UTC)
+	pass := auditOutcome{Report: report.Report{Outcome: report.OutcomePass, ReportURL: "http://iq/report/1"}}
+	failure := auditOutcome{Report: report.Report{Outcome: report.OutcomeFailure, ReportURL: "http://iq/report/2"}}
+
+	w.report(now, pass, nil)
+	w.report(now, pass, nil)
+	w.report(now, failure, nil)
+	w.report(now, auditOutcome{}, errors.New("connection refused"))
+
+	assert.Equal(t, `2020-10-01T12:00:00Z Policy outcome: Pass
+Report URL:  http://iq/report/1
+2020-10-01T12:00:00Z Policy outcome changed from Pass to Failure
+Report URL:  http://iq/report/2
+2020-10-01T12:00:00Z Policy outcome changed from Failure to Error: connection refused
+`, out.String())
+}
+
+func TestWatchCommandMissingPath(t *testing.T) {
+	validateConfigFryError(t,
+		"Path not set, see usage for more information",
+		types.Config{},
+		"watch", "--application=testapp")
+}
+
+func TestWatchExclude(t *testing.T) {
+	exclude := watchExclude(&types.Config{CacheDir: "build/.hashbrowns-cache", NewOnly: true, StateDir: "build/.hashbrowns-state"})
+
+	assert.Contains(t, exclude, "build/.hashbrowns-cache")
+	assert.Contains(t, exclude, "build/.hashbrowns-state")
+}
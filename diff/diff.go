@@ -0,0 +1,131 @@
+//
+// Copyright © 2020-present Sonatype Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+// Package diff has functions for comparing two lists of sha1s, and remembering what was submitted last time
+package diff
+
+import (
+	"sort"
+
+	"github.com/sonatype-nexus-community/go-sona-types/cyclonedx"
+)
+
+// Modification is a location whose sha1 is different than it was before
+type Modification struct {
+	Location string `json:"location"`
+	OldSha1  string `json:"oldSha1"`
+	NewSha1  string `json:"newSha1"`
+}
+
+// Move is a sha1 that is now found at a different location
+type Move struct {
+	Sha1        string `json:"sha1"`
+	OldLocation string `json:"oldLocation"`
+	NewLocation string `json:"newLocation"`
+}
+
+// Result is the difference between a before and an after list of sha1s
+type Result struct {
+	Added    []cyclonedx.Sha1SBOM
+	Removed  []cyclonedx.Sha1SBOM
+	Moved    []Move
+	Modified []Modification
+}
+
+// Empty reports whether nothing changed
+func (r Result) Empty() bool {
+	return len(r.Added) == 0 && len(r.Removed) == 0 && len(r.Moved) == 0 && len(r.Modified) == 0
+}
+
+// Introduced returns the entries of the after list that weren't in the before one, either added or modified.
+// Moved entries aren't included, as their sha1 was already in the before list.
+func (r Result) Introduced() (sha1s []cyclonedx.Sha1SBOM) {
+	sha1s = append(sha1s, r.Added...)
+	for _, v := range r.Modified {
+		sha1s = append(sha1s, cyclonedx.Sha1SBOM{Sha1: v.NewSha1, Location: v.Location})
+	}
+	return
+}
+
+// Compare returns what changed going from before to after. A location with a new sha1 is modified, and a sha1 that
+// disappeared from one location and appeared at another is moved.
+func Compare(before []cyclonedx.Sha1SBOM, after []cyclonedx.Sha1SBOM) (result Result) {
+	beforeByLocation := byLocation(before)
+	afterByLocation := byLocation(after)
+
+	for _, v := range after {
+		previous, ok := beforeByLocation[v.Location]
+		switch {
+		case !ok:
+			result.Added = append(result.Added, v)
+		case previous.Sha1 != v.Sha1:
+			result.Modified = append(result.Modified, Modification{Location: v.Location, OldSha1: previous.Sha1, NewSha1: v.Sha1})
+		}
+	}
+
+	for _, v := range before {
+		if _, ok := afterByLocation[v.Location]; !ok {
+			result.Removed = append(result.Removed, v)
+		}
+	}
+
+	result.Added, result.Removed, result.Moved = pairMoves(result.Added, result.Removed)
+
+	sortSha1s(result.Added)
+	sortSha1s(result.Removed)
+	sort.Slice(result.Moved, func(i, j int) bool { return result.Moved[i].NewLocation < result.Moved[j].NewLocation })
+	sort.Slice(result.Modified, func(i, j int) bool { return result.Modified[i].Location < result.Modified[j].Location })
+
+	return
+}
+
+// pairMoves finds sha1s that were both removed and added, and reports them as moved instead
+func pairMoves(added []cyclonedx.Sha1SBOM, removed []cyclonedx.Sha1SBOM) (stillAdded []cyclonedx.Sha1SBOM, stillRemoved []cyclonedx.Sha1SBOM, moved []Move) {
+	removedBySha1 := map[string]cyclonedx.Sha1SBOM{}
+	for _, v := range removed {
+		removedBySha1[v.Sha1] = v
+	}
+
+	movedSha1s := map[string]bool{}
+	for _, v := range added {
+		if previous, ok := removedBySha1[v.Sha1]; ok && !movedSha1s[v.Sha1] {
+			moved = append(moved, Move{Sha1: v.Sha1, OldLocation: previous.Location, NewLocation: v.Location})
+			movedSha1s[v.Sha1] = true
+			continue
+		}
+		stillAdded = append(stillAdded, v)
+	}
+
+	for _, v := range removed {
+		if !movedSha1s[v.Sha1] {
+			stillRemoved = append(stillRemoved, v)
+		}
+	}
+
+	return
+}
+
+func byLocation(sha1s []cyclonedx.Sha1SBOM) map[string]cyclonedx.Sha1SBOM {
+	result := make(map[string]cyclonedx.Sha1SBOM, len(sha1s))
+	for _, v := range sha1s {
+		result[v.Location] = v
+	}
+	return result
+}
+
+func sortSha1s(sha1s []cyclonedx.Sha1SBOM) {
+	sort.Slice(sha1s, func(i, j int) bool { return sha1s[i].Location < sha1s[j].Location })
+}
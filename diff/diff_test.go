@@ -0,0 +1,105 @@
+//
+// Copyright © 2020-present Sonatype Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+package diff
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/sonatype-nexus-community/go-sona-types/cyclonedx"
+	"github.com/stretchr/testify/assert"
+)
+
+var before = []cyclonedx.Sha1SBOM{
+	{Sha1: "aaaa", Location: "lib/unchanged.jar"},
+	{Sha1: "bbbb", Location: "lib/modified.jar"},
+	{Sha1: "cccc", Location: "lib/removed.jar"},
+	{Sha1: "ffff", Location: "lib/old/moved.jar"},
+}
+
+var after = []cyclonedx.Sha1SBOM{
+	{Sha1: "aaaa", Location: "lib/unchanged.jar"},
+	{Sha1: "dddd", Location: "lib/modified.jar"},
+	{Sha1: "eeee", Location: "lib/added.jar"},
+	{Sha1: "ffff", Location: "lib/new/moved.jar"},
+}
+
+func TestCompare(t *testing.T) {
+	result := Compare(before, after)
+
+	assert.Equal(t, []cyclonedx.Sha1SBOM{{Sha1: "eeee", Location: "lib/added.jar"}}, result.Added)
+	assert.Equal(t, []cyclonedx.Sha1SBOM{{Sha1: "cccc", Location: "lib/removed.jar"}}, result.Removed)
+	assert.Equal(t, []Move{{Sha1: "ffff", OldLocation: "lib/old/moved.jar", NewLocation: "lib/new/moved.jar"}}, result.Moved)
+	assert.Equal(t, []Modification{{Location: "lib/modified.jar", OldSha1: "bbbb", NewSha1: "dddd"}}, result.Modified)
+	assert.False(t, result.Empty())
+	assert.Equal(t, []cyclonedx.Sha1SBOM{{Sha1: "eeee", Location: "lib/added.jar"}, {Sha1: "dddd", Location: "lib/modified.jar"}}, result.Introduced())
+}
+
+func TestCompareNothingChanged(t *testing.T) {
+	assert.True(t, Compare(before, before).Empty())
+}
+
+func TestWriteText(t *testing.T) {
+	buf := new(bytes.Buffer)
+
+	assert.NoError(t, Compare(before, after).WriteText(buf))
+	assert.Equal(t, `1 added, 1 removed, 1 moved, 1 modified
+  + eeee  lib/added.jar
+  - cccc  lib/removed.jar
+  > ffff  lib/old/moved.jar -> lib/new/moved.jar
+  ~ bbbb -> dddd  lib/modified.jar
+`, buf.String())
+}
+
+func TestWriteJSONNothingChanged(t *testing.T) {
+	buf := new(bytes.Buffer)
+
+	assert.NoError(t, Compare(before, before).WriteJSON(buf))
+	assert.JSONEq(t, `{"added": [], "removed": [], "moved": [], "modified": []}`, buf.String())
+}
+
+func TestState(t *testing.T) {
+	dir, err := ioutil.TempDir("", "state")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	sha1s, ok, err := LoadState(dir, "my/app", "build")
+	assert.NoError(t, err)
+	assert.False(t, ok)
+	assert.Nil(t, sha1s)
+
+	assert.NoError(t, SaveState(dir, "my/app", "build", before))
+
+	sha1s, ok, err = LoadState(dir, "my/app", "build")
+	assert.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, before, sha1s)
+}
+
+func TestStateKeyedByStage(t *testing.T) {
+	dir, err := ioutil.TempDir("", "state")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	assert.NoError(t, SaveState(dir, "my/app", "build", before))
+
+	sha1s, ok, err := LoadState(dir, "my/app", "release")
+	assert.NoError(t, err)
+	assert.False(t, ok)
+	assert.Nil(t, sha1s)
+}
@@ -0,0 +1,90 @@
+//
+// Copyright © 2020-present Sonatype Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+package diff
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/sonatype-nexus-community/go-sona-types/cyclonedx"
+)
+
+// WriteText writes a human readable summary of r, followed by one line per change
+func (r Result) WriteText(w io.Writer) (err error) {
+	_, err = fmt.Fprintf(w, "%d added, %d removed, %d moved, %d modified\n", len(r.Added), len(r.Removed), len(r.Moved), len(r.Modified))
+	if err != nil {
+		return
+	}
+
+	for _, v := range r.Added {
+		if _, err = fmt.Fprintf(w, "  + %s  %s\n", v.Sha1, v.Location); err != nil {
+			return
+		}
+	}
+	for _, v := range r.Removed {
+		if _, err = fmt.Fprintf(w, "  - %s  %s\n", v.Sha1, v.Location); err != nil {
+			return
+		}
+	}
+	for _, v := range r.Moved {
+		if _, err = fmt.Fprintf(w, "  > %s  %s -> %s\n", v.Sha1, v.OldLocation, v.NewLocation); err != nil {
+			return
+		}
+	}
+	for _, v := range r.Modified {
+		if _, err = fmt.Fprintf(w, "  ~ %s -> %s  %s\n", v.OldSha1, v.NewSha1, v.Location); err != nil {
+			return
+		}
+	}
+
+	return
+}
+
+// jsonEntry gives added and removed sha1s the same lower case keys as everything else in the JSON output
+type jsonEntry struct {
+	Sha1     string `json:"sha1"`
+	Location string `json:"location"`
+}
+
+type jsonResult struct {
+	Added    []jsonEntry    `json:"added"`
+	Removed  []jsonEntry    `json:"removed"`
+	Moved    []Move         `json:"moved"`
+	Modified []Modification `json:"modified"`
+}
+
+// WriteJSON writes r as an indented JSON document, with empty lists rather than nulls for anything unchanged
+func (r Result) WriteJSON(w io.Writer) error {
+	result := jsonResult{
+		Added:    jsonEntries(r.Added),
+		Removed:  jsonEntries(r.Removed),
+		Moved:    append([]Move{}, r.Moved...),
+		Modified: append([]Modification{}, r.Modified...),
+	}
+
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(result)
+}
+
+func jsonEntries(sha1s []cyclonedx.Sha1SBOM) []jsonEntry {
+	entries := make([]jsonEntry, len(sha1s))
+	for i, v := range sha1s {
+		entries[i] = jsonEntry{Sha1: v.Sha1, Location: v.Location}
+	}
+	return entries
+}
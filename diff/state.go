@@ -0,0 +1,80 @@
+//
+// Copyright © 2020-present Sonatype Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+package diff
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+
+	"github.com/sonatype-nexus-community/go-sona-types/cyclonedx"
+	"github.com/sonatype-nexus-community/hashbrowns/types"
+)
+
+const stateDirName = "state"
+
+var unsafeFilenameCharacters = regexp.MustCompile(`[^A-Za-z0-9._-]`)
+
+// DefaultStateDir returns where the last submitted sha1s are kept if no other directory is given
+func DefaultStateDir() (string, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(home, types.HashbrownsDirName, stateDirName), nil
+}
+
+// LoadState returns the sha1s last saved for application and stage in dir, and false if there are none
+func LoadState(dir string, application string, stage string) (sha1s []cyclonedx.Sha1SBOM, ok bool, err error) {
+	content, err := ioutil.ReadFile(statePath(dir, application, stage))
+	if os.IsNotExist(err) {
+		return nil, false, nil
+	}
+	if err != nil {
+		return
+	}
+
+	if err = json.Unmarshal(content, &sha1s); err != nil {
+		return
+	}
+	return sha1s, true, nil
+}
+
+// SaveState remembers sha1s as the last submitted for application and stage in dir
+func SaveState(dir string, application string, stage string, sha1s []cyclonedx.Sha1SBOM) (err error) {
+	path := statePath(dir, application, stage)
+	if err = os.MkdirAll(filepath.Dir(path), os.ModePerm); err != nil {
+		return
+	}
+
+	content, err := json.Marshal(sha1s)
+	if err != nil {
+		return
+	}
+	return ioutil.WriteFile(path, content, 0644)
+}
+
+// statePath is where the state of application and stage is kept in dir, in a directory for the application with a
+// file for each stage
+func statePath(dir string, application string, stage string) string {
+	return filepath.Join(dir, safeFilename(application), safeFilename(stage)+".json")
+}
+
+func safeFilename(name string) string {
+	return unsafeFilenameCharacters.ReplaceAllString(name, "_")
+}
@@ -0,0 +1,179 @@
+//
+// Copyright © 2020-present Sonatype Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+// Package filter has functions for narrowing a list of sha1s down to the entries likely to be third party artifacts
+package filter
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+	"github.com/sonatype-nexus-community/go-sona-types/cyclonedx"
+	"github.com/sonatype-nexus-community/hashbrowns/hasher"
+	"github.com/sonatype-nexus-community/hashbrowns/logger"
+)
+
+// Presets are the file extensions of artifacts for common ecosystems
+var Presets = map[string][]string{
+	"java":   {".jar", ".war", ".ear"},
+	"js":     {".js", ".tgz"},
+	"python": {".whl", ".egg"},
+	"dotnet": {".dll", ".nupkg"},
+	"native": {".so", ".dll"},
+}
+
+// LibraryExtensions are the file extensions that image --libraries-only keeps
+var LibraryExtensions = []string{".jar", ".war", ".ear", ".whl", ".egg", ".nupkg", ".dll", ".so", ".tgz"}
+
+var log = logger.Logger()
+
+// Options configures which entries are kept. Entries must match a preset or extension (if any are set), and be
+// within the size limits (if set). Size limits apply to the file on disk at the location of an entry, or to the
+// archive on disk that an entry is nested inside of.
+type Options struct {
+	Presets    []string
+	Extensions []string
+	MinSize    int64
+	MaxSize    int64
+}
+
+// PresetNames returns the names of all built in presets
+func PresetNames() (names []string) {
+	for k := range Presets {
+		names = append(names, k)
+	}
+	sort.Strings(names)
+	return
+}
+
+// Enabled reports whether opts would filter anything out
+func (opts Options) Enabled() bool {
+	return len(opts.Presets) > 0 || len(opts.Extensions) > 0 || opts.MinSize > 0 || opts.MaxSize > 0
+}
+
+// Validate returns an error if opts names a preset that doesn't exist
+func (opts Options) Validate() error {
+	for _, v := range opts.Presets {
+		if _, ok := Presets[strings.ToLower(v)]; !ok {
+			return fmt.Errorf("Unknown filter preset %q, supported presets are: %s", v, strings.Join(PresetNames(), ", "))
+		}
+	}
+	return nil
+}
+
+// MatchesName reports whether name has one of the extensions selected by opts, or true if none are selected
+func (opts Options) MatchesName(name string) bool {
+	extensions := opts.extensions()
+	if len(extensions) == 0 {
+		return true
+	}
+
+	base := strings.ToLower(path.Base(name))
+	for _, v := range extensions {
+		if strings.HasSuffix(base, v) {
+			return true
+		}
+		// Shared objects are usually versioned, like libssl.so.1.1
+		if v == ".so" && strings.Contains(base, ".so.") {
+			return true
+		}
+	}
+	return false
+}
+
+// MatchesSize reports whether size is within the limits set by opts
+func (opts Options) MatchesSize(size int64) bool {
+	if opts.MinSize > 0 && size < opts.MinSize {
+		return false
+	}
+	if opts.MaxSize > 0 && size > opts.MaxSize {
+		return false
+	}
+	return true
+}
+
+// Matches reports whether a file called name, of size bytes, is kept by opts
+func (opts Options) Matches(name string, size int64) bool {
+	return opts.MatchesName(name) && opts.MatchesSize(size)
+}
+
+func (opts Options) sized() bool {
+	return opts.MinSize > 0 || opts.MaxSize > 0
+}
+
+// sizeOnDisk returns the size of the file at location, or of the archive it is nested inside of, if it is on disk
+func sizeOnDisk(location string) (int64, bool) {
+	if i := strings.Index(location, hasher.NestedSeparator); i >= 0 {
+		location = location[:i]
+	}
+
+	info, err := os.Stat(location)
+	if err != nil || !info.Mode().IsRegular() {
+		return 0, false
+	}
+	return info.Size(), true
+}
+
+func (opts Options) extensions() (extensions []string) {
+	for _, v := range opts.Presets {
+		extensions = append(extensions, Presets[strings.ToLower(v)]...)
+	}
+	for _, v := range opts.Extensions {
+		v = strings.ToLower(v)
+		if !strings.HasPrefix(v, ".") {
+			v = "." + v
+		}
+		extensions = append(extensions, v)
+	}
+	return
+}
+
+// Apply returns the sha1s that pass opts, how many were filtered out, and how many were kept without checking their
+// size because they aren't on disk
+func Apply(sha1s []cyclonedx.Sha1SBOM, opts Options) (kept []cyclonedx.Sha1SBOM, filtered int, unsized int, err error) {
+	if err = opts.Validate(); err != nil {
+		return
+	}
+
+	for _, v := range sha1s {
+		matches := opts.MatchesName(v.Location)
+		if matches && opts.sized() {
+			if size, ok := sizeOnDisk(v.Location); ok {
+				matches = opts.MatchesSize(size)
+			} else {
+				unsized++
+			}
+		}
+		if matches {
+			kept = append(kept, v)
+			continue
+		}
+		log.WithField("sha1", v).Trace("Filtered out sha1")
+		filtered++
+	}
+
+	log.WithFields(logrus.Fields{
+		"kept":     len(kept),
+		"filtered": filtered,
+		"unsized":  unsized,
+	}).Debug("Finished filtering sha1s")
+
+	return
+}
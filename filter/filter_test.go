@@ -0,0 +1,111 @@
+//
+// Copyright © 2020-present Sonatype Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+package filter
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/sonatype-nexus-community/go-sona-types/cyclonedx"
+	"github.com/stretchr/testify/assert"
+)
+
+var entries = []cyclonedx.Sha1SBOM{
+	{Sha1: "1", Location: "/opt/app/lib/foo.jar"},
+	{Sha1: "2", Location: "/opt/app/conf/app.properties"},
+	{Sha1: "3", Location: "/usr/lib/libssl.so.1.1"},
+	{Sha1: "4", Location: "app.war!/WEB-INF/lib/bar.JAR"},
+	{Sha1: "5", Location: "/var/log/app.log"},
+}
+
+func sha1s(results []cyclonedx.Sha1SBOM) (result []string) {
+	for _, v := range results {
+		result = append(result, v.Sha1)
+	}
+	return
+}
+
+func TestApplyNoOptions(t *testing.T) {
+	kept, filtered, _, err := Apply(entries, Options{})
+
+	assert.NoError(t, err)
+	assert.Equal(t, entries, kept)
+	assert.Equal(t, 0, filtered)
+}
+
+func TestApplyPresets(t *testing.T) {
+	kept, filtered, _, err := Apply(entries, Options{Presets: []string{"java", "native"}})
+
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"1", "3", "4"}, sha1s(kept))
+	assert.Equal(t, 2, filtered)
+}
+
+func TestApplyExtensions(t *testing.T) {
+	kept, filtered, _, err := Apply(entries, Options{Extensions: []string{"log", ".properties"}})
+
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"2", "5"}, sha1s(kept))
+	assert.Equal(t, 3, filtered)
+}
+
+func TestApplyUnknownPreset(t *testing.T) {
+	kept, _, _, err := Apply(entries, Options{Presets: []string{"cobol"}})
+
+	assert.Nil(t, kept)
+	assert.Equal(t, "Unknown filter preset \"cobol\", supported presets are: dotnet, java, js, native, python", err.Error())
+}
+
+func TestApplySize(t *testing.T) {
+	dir, err := ioutil.TempDir("", "filter")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	small := filepath.Join(dir, "small.jar")
+	large := filepath.Join(dir, "large.war")
+	assert.NoError(t, ioutil.WriteFile(small, []byte("a"), 0644))
+	assert.NoError(t, ioutil.WriteFile(large, make([]byte, 1024), 0644))
+
+	input := []cyclonedx.Sha1SBOM{
+		{Sha1: "1", Location: small},
+		{Sha1: "2", Location: large},
+		{Sha1: "3", Location: large + "!/WEB-INF/lib/tiny.jar"},
+		{Sha1: "4", Location: filepath.Join(dir, "missing.jar")},
+	}
+
+	kept, filtered, unsized, err := Apply(input, Options{MinSize: 10})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"2", "3", "4"}, sha1s(kept))
+	assert.Equal(t, 1, filtered)
+	assert.Equal(t, 1, unsized)
+
+	kept, filtered, unsized, err = Apply(input, Options{MaxSize: 10})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"1", "4"}, sha1s(kept))
+	assert.Equal(t, 2, filtered)
+	assert.Equal(t, 1, unsized)
+}
+
+func TestMatches(t *testing.T) {
+	opts := Options{Presets: []string{"java"}, MinSize: 10, MaxSize: 100}
+
+	assert.True(t, opts.Matches("usr/share/java/foo.jar", 50))
+	assert.False(t, opts.Matches("usr/share/java/foo.jar", 5))
+	assert.False(t, opts.Matches("usr/share/java/foo.jar", 500))
+	assert.False(t, opts.Matches("etc/app.conf", 50))
+}
@@ -4,7 +4,7 @@ go 1.14
 
 require (
 	github.com/common-nighthawk/go-figure v0.0.0-20200609044655-c4b36f998cf2
-	github.com/fsnotify/fsnotify v1.4.9 // indirect
+	github.com/fsnotify/fsnotify v1.4.9
 	github.com/jarcoal/httpmock v1.0.5
 	github.com/magiconair/properties v1.8.2 // indirect
 	github.com/mitchellh/go-homedir v1.1.0
@@ -18,6 +18,7 @@ require (
 	github.com/spf13/viper v1.7.1
 	github.com/stretchr/testify v1.6.1
 	gopkg.in/ini.v1 v1.60.2 // indirect
+	gopkg.in/yaml.v2 v2.3.0
 )
 
 // fix vulnerability: CVE-2021-38561 in golang.org/x/text@v0.3.3
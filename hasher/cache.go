@@ -0,0 +1,194 @@
+//
+// Copyright © 2020-present Sonatype Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+package hasher
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/sonatype-nexus-community/go-sona-types/cyclonedx"
+)
+
+const cacheFilename = "hashes.json"
+
+// Cache remembers the sha1s of files on disk, keyed by path, size, modification time and inode, so that files
+// which haven't changed since the last run are not hashed again
+type Cache struct {
+	Hits   int
+	Misses int
+
+	path    string
+	mu      sync.Mutex
+	entries map[string]cacheEntry
+	// seen holds the keys of the entries used since the cache was opened or last saved
+	seen map[string]bool
+	// roots holds the directories walked since the cache was opened or last saved
+	roots []string
+	dirty bool
+}
+
+// cacheEntry holds the sha1s for a file, with locations relative to the file so the same file reached by a different
+// path still gets the right locations
+type cacheEntry struct {
+	File  fileKey              `json:"file"`
+	Sha1s []cyclonedx.Sha1SBOM `json:"sha1s"`
+}
+
+// fileKey is what has to match for a cached entry to still be valid
+type fileKey struct {
+	Size         int64  `json:"size"`
+	ModTime      int64  `json:"mtime"`
+	Inode        uint64 `json:"inode"`
+	ArchiveDepth int    `json:"archiveDepth"`
+}
+
+// OpenCache loads the cache kept in dir, creating dir if needed. If clear is true, any existing cache is discarded.
+func OpenCache(dir string, clear bool) (cache *Cache, err error) {
+	if err = os.MkdirAll(dir, os.ModePerm); err != nil {
+		return
+	}
+
+	cache = &Cache{path: filepath.Join(dir, cacheFilename), entries: map[string]cacheEntry{}, seen: map[string]bool{}}
+
+	if clear {
+		cache.dirty = true
+		return
+	}
+
+	content, err := ioutil.ReadFile(cache.path)
+	if os.IsNotExist(err) {
+		return cache, nil
+	}
+	if err != nil {
+		return
+	}
+	if err = json.Unmarshal(content, &cache.entries); err != nil {
+		// A corrupt cache only costs us a rehash, so start over rather than failing the run
+		cache.entries = map[string]cacheEntry{}
+		cache.dirty = true
+		return cache, nil
+	}
+
+	return
+}
+
+// Save writes the cache back to disk, if anything in it changed. Entries under the directories walked since the cache
+// was opened or last saved are dropped if they weren't used, so files that no longer exist don't stay in the cache
+// forever. Entries for other directories sharing the cache are kept.
+func (c *Cache) Save() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for k := range c.entries {
+		if !c.seen[k] && c.under(k) {
+			delete(c.entries, k)
+			c.dirty = true
+		}
+	}
+	c.seen = map[string]bool{}
+	c.roots = nil
+
+	if !c.dirty {
+		return nil
+	}
+
+	content, err := json.Marshal(c.entries)
+	if err != nil {
+		return err
+	}
+	if err = ioutil.WriteFile(c.path, content, 0644); err != nil {
+		return err
+	}
+	c.dirty = false
+
+	return nil
+}
+
+func (c *Cache) get(path string, info os.FileInfo, archiveDepth int) ([]cyclonedx.Sha1SBOM, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	key := cacheKey(path)
+	entry, ok := c.entries[key]
+	if ok && entry.File == newFileKey(info, archiveDepth) {
+		c.Hits++
+		c.seen[key] = true
+		sha1s := make([]cyclonedx.Sha1SBOM, len(entry.Sha1s))
+		for i, v := range entry.Sha1s {
+			sha1s[i] = cyclonedx.Sha1SBOM{Sha1: v.Sha1, Location: path + v.Location}
+		}
+		return sha1s, true
+	}
+	c.Misses++
+	return nil, false
+}
+
+func (c *Cache) put(path string, info os.FileInfo, archiveDepth int, sha1s []cyclonedx.Sha1SBOM) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	relative := make([]cyclonedx.Sha1SBOM, len(sha1s))
+	for i, v := range sha1s {
+		relative[i] = cyclonedx.Sha1SBOM{Sha1: v.Sha1, Location: strings.TrimPrefix(v.Location, path)}
+	}
+	key := cacheKey(path)
+	c.entries[key] = cacheEntry{File: newFileKey(info, archiveDepth), Sha1s: relative}
+	c.seen[key] = true
+	c.dirty = true
+}
+
+// walking records that root is being walked, so Save knows which entries it can tell are stale
+func (c *Cache) walking(root string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.roots = append(c.roots, cacheKey(root))
+}
+
+// under reports whether key is inside of one of the directories walked since the cache was opened or last saved
+func (c *Cache) under(key string) bool {
+	for _, root := range c.roots {
+		if key == root || strings.HasPrefix(key, strings.TrimSuffix(root, string(filepath.Separator))+string(filepath.Separator)) {
+			return true
+		}
+	}
+	return false
+}
+
+// holds reports whether path is the directory the cache is kept in, so it can be left out when hashing
+func (c *Cache) holds(path string) bool {
+	return cacheKey(path) == cacheKey(filepath.Dir(c.path))
+}
+
+func newFileKey(info os.FileInfo, archiveDepth int) fileKey {
+	return fileKey{
+		Size:         info.Size(),
+		ModTime:      info.ModTime().UnixNano(),
+		Inode:        inode(info),
+		ArchiveDepth: archiveDepth,
+	}
+}
+
+func cacheKey(path string) string {
+	if abs, err := filepath.Abs(path); err == nil {
+		return abs
+	}
+	return path
+}
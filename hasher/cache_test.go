@@ -0,0 +1,171 @@
+//
+// Copyright © 2020-present Sonatype Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+package hasher
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCacheHitsUnchangedFiles(t *testing.T) {
+	dir := setupWar(t)
+	cacheDir := filepath.Join(dir, ".cache")
+	war := filepath.Join(dir, "app.war")
+
+	cache, err := OpenCache(cacheDir, false)
+	assert.NoError(t, err)
+	first, err := File(war, Options{ArchiveDepth: 2, Cache: cache})
+	assert.NoError(t, err)
+	assert.Equal(t, 0, cache.Hits)
+	assert.Equal(t, 1, cache.Misses)
+	assert.NoError(t, cache.Save())
+
+	cache, err = OpenCache(cacheDir, false)
+	assert.NoError(t, err)
+	second, err := File(war, Options{ArchiveDepth: 2, Cache: cache})
+	assert.NoError(t, err)
+	assert.Equal(t, first, second)
+	assert.Equal(t, 1, cache.Hits)
+	assert.Equal(t, 0, cache.Misses)
+}
+
+func TestCacheMissesChangedFiles(t *testing.T) {
+	dir := setupWar(t)
+	cacheDir := filepath.Join(dir, ".cache")
+	war := filepath.Join(dir, "app.war")
+
+	cache, err := OpenCache(cacheDir, false)
+	assert.NoError(t, err)
+	_, err = File(war, Options{Cache: cache})
+	assert.NoError(t, err)
+
+	// A different archive depth gives different results, so can't reuse the cached entry
+	_, err = File(war, Options{ArchiveDepth: 1, Cache: cache})
+	assert.NoError(t, err)
+	assert.Equal(t, 0, cache.Hits)
+
+	assert.NoError(t, ioutil.WriteFile(war, []byte("hello"), 0644))
+	later := time.Now().Add(time.Minute)
+	assert.NoError(t, os.Chtimes(war, later, later))
+
+	results, err := File(war, Options{ArchiveDepth: 1, Cache: cache})
+	assert.NoError(t, err)
+	assert.Equal(t, helloSha1, results[0].Sha1)
+	assert.Equal(t, 0, cache.Hits)
+	assert.Equal(t, 3, cache.Misses)
+}
+
+func TestCacheClear(t *testing.T) {
+	dir := setupWar(t)
+	cacheDir := filepath.Join(dir, ".cache")
+	war := filepath.Join(dir, "app.war")
+
+	cache, err := OpenCache(cacheDir, false)
+	assert.NoError(t, err)
+	_, err = File(war, Options{Cache: cache})
+	assert.NoError(t, err)
+	assert.NoError(t, cache.Save())
+
+	cache, err = OpenCache(cacheDir, true)
+	assert.NoError(t, err)
+	_, err = File(war, Options{Cache: cache})
+	assert.NoError(t, err)
+	assert.Equal(t, 0, cache.Hits)
+	assert.Equal(t, 1, cache.Misses)
+}
+
+func TestCacheSkippedWhenHashingDir(t *testing.T) {
+	dir := setupWar(t)
+
+	cache, err := OpenCache(filepath.Join(dir, ".cache"), false)
+	assert.NoError(t, err)
+	_, err = Dir(dir, Options{Cache: cache})
+	assert.NoError(t, err)
+	assert.NoError(t, cache.Save())
+
+	results, err := Dir(dir, Options{Cache: cache})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{filepath.Join(dir, "app.war")}, locations(results))
+}
+
+func TestCacheSavePrunesUnseenFiles(t *testing.T) {
+	dir := setupWar(t)
+	cacheDir := filepath.Join(dir, ".cache")
+	war := filepath.Join(dir, "app.war")
+	removed := filepath.Join(dir, "removed.txt")
+	assert.NoError(t, ioutil.WriteFile(removed, []byte("hello"), 0644))
+
+	cache, err := OpenCache(cacheDir, false)
+	assert.NoError(t, err)
+	_, err = Dir(dir, Options{Cache: cache})
+	assert.NoError(t, err)
+	assert.NoError(t, cache.Save())
+	assert.Equal(t, 2, len(cache.entries))
+
+	assert.NoError(t, os.Remove(removed))
+	cache, err = OpenCache(cacheDir, false)
+	assert.NoError(t, err)
+	_, err = Dir(dir, Options{Cache: cache})
+	assert.NoError(t, err)
+	assert.NoError(t, cache.Save())
+
+	cache, err = OpenCache(cacheDir, false)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{cacheKey(war)}, keys(cache.entries))
+
+	// Saving without hashing anything keeps every entry
+	assert.NoError(t, cache.Save())
+	cache, err = OpenCache(cacheDir, false)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, len(cache.entries))
+}
+
+func TestCacheSaveKeepsOtherRoots(t *testing.T) {
+	first := setupWar(t)
+	second := setupWar(t)
+	cacheDir, err := ioutil.TempDir("", "cache")
+	assert.NoError(t, err)
+	t.Cleanup(func() { os.RemoveAll(cacheDir) })
+
+	cache, err := OpenCache(cacheDir, false)
+	assert.NoError(t, err)
+	_, err = Dir(first, Options{Cache: cache})
+	assert.NoError(t, err)
+	assert.NoError(t, cache.Save())
+
+	cache, err = OpenCache(cacheDir, false)
+	assert.NoError(t, err)
+	_, err = Dir(second, Options{Cache: cache})
+	assert.NoError(t, err)
+	assert.NoError(t, cache.Save())
+
+	cache, err = OpenCache(cacheDir, false)
+	assert.NoError(t, err)
+	assert.ElementsMatch(t, []string{cacheKey(filepath.Join(first, "app.war")), cacheKey(filepath.Join(second, "app.war"))},
+		keys(cache.entries))
+}
+
+func keys(entries map[string]cacheEntry) (result []string) {
+	for k := range entries {
+		result = append(result, k)
+	}
+	return
+}
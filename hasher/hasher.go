@@ -0,0 +1,348 @@
+//
+// Copyright © 2020-present Sonatype Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+// Package hasher has functions for obtaining the sha1s of files on disk, including files nested inside of archives
+package hasher
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"crypto/sha1"
+	"encoding/hex"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+	"github.com/sonatype-nexus-community/go-sona-types/cyclonedx"
+	"github.com/sonatype-nexus-community/hashbrowns/logger"
+)
+
+// NestedSeparator separates an archive from the path of an entry inside of it, for example app.war!/WEB-INF/lib/foo.jar
+const NestedSeparator = "!/"
+
+var zipExtensions = []string{".zip", ".jar", ".war", ".ear", ".aar", ".nupkg", ".whl"}
+
+var tarExtensions = []string{".tar"}
+
+var gzipTarExtensions = []string{".tar.gz", ".tgz"}
+
+// maxNestedSize is the largest archive nested inside of another that is read into memory to hash the entries of.
+// Larger ones are only hashed themselves, so one large or crafted entry can't use up all of the memory.
+var maxNestedSize int64 = 256 << 20
+
+var log = logger.Logger()
+
+// Options configures how files are hashed
+type Options struct {
+	// ArchiveDepth is how many levels of archives to hash the entries of, 0 only hashes the archive itself
+	ArchiveDepth int
+	// Cache, if set, is used to skip hashing files on disk that haven't changed
+	Cache *Cache
+	// OnFile, if set, is called by Dir with the path and size of each file once it has been hashed
+	OnFile func(path string, size int64)
+}
+
+// Dir walks root, and returns the sha1 and location of every regular file in it, as a slice of types.Sha1SBOM
+func Dir(root string, opts Options) (sha1s []cyclonedx.Sha1SBOM, err error) {
+	log.WithFields(logrus.Fields{
+		"root":          root,
+		"archive_depth": opts.ArchiveDepth,
+	}).Info("Beginning to hash directory")
+	if opts.Cache != nil {
+		opts.Cache.walking(root)
+	}
+	err = filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return skipUnreadable(path, err)
+		}
+		if info.IsDir() && opts.Cache != nil && opts.Cache.holds(path) {
+			return filepath.SkipDir
+		}
+		if !info.Mode().IsRegular() {
+			return nil
+		}
+
+		hashed, err := File(path, opts)
+		if err != nil {
+			return skipUnreadable(path, err)
+		}
+		sha1s = append(sha1s, hashed...)
+		if opts.OnFile != nil {
+			opts.OnFile(path, info.Size())
+		}
+
+		return nil
+	})
+
+	return
+}
+
+// skipUnreadable lets a walk carry on past files and directories it doesn't have permission to read, so one of them
+// doesn't stop a whole directory from being hashed
+func skipUnreadable(path string, err error) error {
+	if !os.IsPermission(err) {
+		return err
+	}
+	log.WithFields(logrus.Fields{
+		"error": err,
+		"path":  path,
+	}).Warn("Skipping path that can't be read")
+
+	return nil
+}
+
+// File returns the sha1 of the file at path, followed by the sha1s of any entries nested inside of it
+// if it is an archive and opts.ArchiveDepth allows
+func File(path string, opts Options) (sha1s []cyclonedx.Sha1SBOM, err error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return
+	}
+	defer file.Close()
+
+	info, err := file.Stat()
+	if err != nil {
+		return
+	}
+
+	if opts.Cache != nil {
+		if cached, ok := opts.Cache.get(path, info, opts.ArchiveDepth); ok {
+			log.WithField("path", path).Trace("Unchanged file found in cache, using cached sha1s")
+			return cached, nil
+		}
+		defer func() {
+			if err == nil {
+				opts.Cache.put(path, info, opts.ArchiveDepth, sha1s)
+			}
+		}()
+	}
+
+	sum, err := sha1Of(file)
+	if err != nil {
+		return
+	}
+	sha1s = append(sha1s, cyclonedx.Sha1SBOM{Sha1: sum, Location: path})
+
+	if opts.ArchiveDepth < 1 || archiveKind(path) == notArchive {
+		return
+	}
+
+	if _, err = file.Seek(0, io.SeekStart); err != nil {
+		return
+	}
+
+	nested, err := archiveEntries(path, file, info.Size(), opts.ArchiveDepth)
+	if err != nil {
+		log.WithFields(logrus.Fields{
+			"error": err,
+			"path":  path,
+		}).Warn("Unable to read archive, only hashing the archive itself")
+
+		return sha1s, nil
+	}
+	sha1s = append(sha1s, nested...)
+
+	return
+}
+
+// Reader returns the sha1 of the content of r, reported at location, followed by the sha1s of any entries nested
+// inside of it if location names an archive and opts.ArchiveDepth allows. Archives are read into memory, unless they
+// are too large to, in which case only the archive itself is hashed.
+func Reader(location string, r io.Reader, opts Options) (sha1s []cyclonedx.Sha1SBOM, err error) {
+	if opts.ArchiveDepth < 1 || archiveKind(location) == notArchive {
+		sum, err := sha1Of(r)
+		if err != nil {
+			return nil, err
+		}
+		return []cyclonedx.Sha1SBOM{{Sha1: sum, Location: location}}, nil
+	}
+
+	sum, content, err := readNested(location, r)
+	if err != nil {
+		return
+	}
+	return nestedArchive(location, sum, content, opts.ArchiveDepth), nil
+}
+
+type kind int
+
+const (
+	notArchive kind = iota
+	zipArchive
+	tarArchive
+	gzipTarArchive
+)
+
+func archiveKind(name string) kind {
+	name = strings.ToLower(name)
+	for _, v := range gzipTarExtensions {
+		if strings.HasSuffix(name, v) {
+			return gzipTarArchive
+		}
+	}
+	for _, v := range tarExtensions {
+		if strings.HasSuffix(name, v) {
+			return tarArchive
+		}
+	}
+	for _, v := range zipExtensions {
+		if strings.HasSuffix(name, v) {
+			return zipArchive
+		}
+	}
+	return notArchive
+}
+
+func archiveEntries(location string, r io.ReaderAt, size int64, depth int) ([]cyclonedx.Sha1SBOM, error) {
+	switch archiveKind(location) {
+	case zipArchive:
+		return zipEntries(location, r, size, depth)
+	case tarArchive:
+		return tarEntries(location, io.NewSectionReader(r, 0, size), depth)
+	case gzipTarArchive:
+		gz, err := gzip.NewReader(io.NewSectionReader(r, 0, size))
+		if err != nil {
+			return nil, err
+		}
+		defer gz.Close()
+		return tarEntries(location, gz, depth)
+	}
+	return nil, nil
+}
+
+func zipEntries(location string, r io.ReaderAt, size int64, depth int) (sha1s []cyclonedx.Sha1SBOM, err error) {
+	zr, err := zip.NewReader(r, size)
+	if err != nil {
+		return
+	}
+
+	for _, f := range zr.File {
+		if f.FileInfo().IsDir() {
+			continue
+		}
+
+		rc, err := f.Open()
+		if err != nil {
+			return nil, err
+		}
+		hashed, err := entry(location+NestedSeparator+f.Name, rc, depth)
+		rc.Close()
+		if err != nil {
+			return nil, err
+		}
+		sha1s = append(sha1s, hashed...)
+	}
+
+	return
+}
+
+func tarEntries(location string, r io.Reader, depth int) (sha1s []cyclonedx.Sha1SBOM, err error) {
+	tr := tar.NewReader(r)
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		if !header.FileInfo().Mode().IsRegular() {
+			continue
+		}
+
+		hashed, err := entry(location+NestedSeparator+strings.TrimPrefix(header.Name, "./"), tr, depth)
+		if err != nil {
+			return nil, err
+		}
+		sha1s = append(sha1s, hashed...)
+	}
+
+	return
+}
+
+// entry hashes a single entry of an archive at the given depth, recursing into it if it is itself an archive
+func entry(location string, r io.Reader, depth int) ([]cyclonedx.Sha1SBOM, error) {
+	if depth <= 1 || archiveKind(location) == notArchive {
+		sum, err := sha1Of(r)
+		if err != nil {
+			return nil, err
+		}
+		return []cyclonedx.Sha1SBOM{{Sha1: sum, Location: location}}, nil
+	}
+
+	sum, content, err := readNested(location, r)
+	if err != nil {
+		return nil, err
+	}
+	return nestedArchive(location, sum, content, depth-1), nil
+}
+
+// readNested returns the sha1 of an archive read from r, and its content if it is no larger than maxNestedSize
+func readNested(location string, r io.Reader) (sum string, content []byte, err error) {
+	h := sha1.New()
+	buf := new(bytes.Buffer)
+	n, err := io.Copy(io.MultiWriter(h, buf), io.LimitReader(r, maxNestedSize+1))
+	if err != nil {
+		return
+	}
+	content = buf.Bytes()
+
+	if n > maxNestedSize {
+		log.WithFields(logrus.Fields{
+			"location": location,
+			"max_size": maxNestedSize,
+		}).Warn("Nested archive is too large to read into memory, only hashing the archive itself")
+		content = nil
+		if _, err = io.Copy(h, r); err != nil {
+			return
+		}
+	}
+
+	return hex.EncodeToString(h.Sum(nil)), content, nil
+}
+
+// nestedArchive returns the sha1 of an in memory archive, followed by the sha1s of its entries. Content is nil if the
+// archive was too large to read into memory, so has no entries.
+func nestedArchive(location string, sum string, content []byte, depth int) (sha1s []cyclonedx.Sha1SBOM) {
+	sha1s = append(sha1s, cyclonedx.Sha1SBOM{Sha1: sum, Location: location})
+	if content == nil {
+		return
+	}
+
+	nested, err := archiveEntries(location, bytes.NewReader(content), int64(len(content)), depth)
+	if err != nil {
+		log.WithFields(logrus.Fields{
+			"error":    err,
+			"location": location,
+		}).Warn("Unable to read nested archive, only hashing the archive itself")
+
+		return
+	}
+
+	return append(sha1s, nested...)
+}
+
+func sha1Of(r io.Reader) (string, error) {
+	h := sha1.New()
+	if _, err := io.Copy(h, r); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
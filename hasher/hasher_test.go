@@ -0,0 +1,166 @@
+//
+// Copyright © 2020-present Sonatype Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+package hasher
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/sonatype-nexus-community/go-sona-types/cyclonedx"
+	"github.com/stretchr/testify/assert"
+)
+
+const helloSha1 = "aaf4c61ddcc5e8a2dabede0f3b482cd9aea9434d"
+
+func zipOf(t *testing.T, files map[string][]byte) []byte {
+	buf := new(bytes.Buffer)
+	zw := zip.NewWriter(buf)
+	for name, content := range files {
+		w, err := zw.Create(name)
+		assert.NoError(t, err)
+		_, err = w.Write(content)
+		assert.NoError(t, err)
+	}
+	assert.NoError(t, zw.Close())
+	return buf.Bytes()
+}
+
+func tgzOf(t *testing.T, files map[string][]byte) []byte {
+	buf := new(bytes.Buffer)
+	gz := gzip.NewWriter(buf)
+	tw := tar.NewWriter(gz)
+	for name, content := range files {
+		assert.NoError(t, tw.WriteHeader(&tar.Header{Name: name, Mode: 0644, Size: int64(len(content)), Typeflag: tar.TypeReg}))
+		_, err := tw.Write(content)
+		assert.NoError(t, err)
+	}
+	assert.NoError(t, tw.Close())
+	assert.NoError(t, gz.Close())
+	return buf.Bytes()
+}
+
+func setupWar(t *testing.T) string {
+	dir, err := ioutil.TempDir("", "hasher")
+	assert.NoError(t, err)
+	t.Cleanup(func() { os.RemoveAll(dir) })
+
+	jar := zipOf(t, map[string][]byte{"hello.txt": []byte("hello")})
+	war := zipOf(t, map[string][]byte{"WEB-INF/lib/foo.jar": jar})
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(dir, "app.war"), war, 0644))
+
+	return dir
+}
+
+func locations(sha1s []cyclonedx.Sha1SBOM) (result []string) {
+	for _, v := range sha1s {
+		result = append(result, v.Location)
+	}
+	return
+}
+
+func TestDirNoArchiveDepth(t *testing.T) {
+	dir := setupWar(t)
+
+	results, err := Dir(dir, Options{})
+
+	assert.NoError(t, err)
+	assert.Equal(t, []string{filepath.Join(dir, "app.war")}, locations(results))
+}
+
+func TestDirArchiveDepthOne(t *testing.T) {
+	dir := setupWar(t)
+	war := filepath.Join(dir, "app.war")
+
+	results, err := Dir(dir, Options{ArchiveDepth: 1})
+
+	assert.NoError(t, err)
+	assert.Equal(t, []string{war, war + "!/WEB-INF/lib/foo.jar"}, locations(results))
+}
+
+func TestDirArchiveDepthTwo(t *testing.T) {
+	dir := setupWar(t)
+	war := filepath.Join(dir, "app.war")
+
+	results, err := Dir(dir, Options{ArchiveDepth: 2})
+
+	assert.NoError(t, err)
+	assert.Equal(t, []string{war, war + "!/WEB-INF/lib/foo.jar", war + "!/WEB-INF/lib/foo.jar!/hello.txt"}, locations(results))
+	assert.Equal(t, helloSha1, results[2].Sha1)
+}
+
+func TestReaderTarball(t *testing.T) {
+	tgz := tgzOf(t, map[string][]byte{"./package/hello.txt": []byte("hello")})
+
+	results, err := Reader("image.tgz", bytes.NewReader(tgz), Options{ArchiveDepth: 1})
+
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"image.tgz", "image.tgz!/package/hello.txt"}, locations(results))
+	assert.Equal(t, helloSha1, results[1].Sha1)
+}
+
+func TestReaderNotAnArchive(t *testing.T) {
+	results, err := Reader("hello.txt", bytes.NewReader([]byte("hello")), Options{ArchiveDepth: 3})
+
+	assert.NoError(t, err)
+	assert.Equal(t, []cyclonedx.Sha1SBOM{{Sha1: helloSha1, Location: "hello.txt"}}, results)
+}
+
+func TestReaderCorruptArchive(t *testing.T) {
+	results, err := Reader("broken.jar", bytes.NewReader([]byte("hello")), Options{ArchiveDepth: 1})
+
+	assert.NoError(t, err)
+	assert.Equal(t, []cyclonedx.Sha1SBOM{{Sha1: helloSha1, Location: "broken.jar"}}, results)
+}
+
+func TestDirNestedArchiveTooLarge(t *testing.T) {
+	origMaxNestedSize := maxNestedSize
+	t.Cleanup(func() { maxNestedSize = origMaxNestedSize })
+	maxNestedSize = 16
+
+	dir := setupWar(t)
+	war := filepath.Join(dir, "app.war")
+
+	results, err := Dir(dir, Options{ArchiveDepth: 2})
+
+	assert.NoError(t, err)
+	assert.Equal(t, []string{war, war + "!/WEB-INF/lib/foo.jar"}, locations(results))
+
+	jar, err := Dir(dir, Options{ArchiveDepth: 1})
+	assert.NoError(t, err)
+	assert.Equal(t, jar[1].Sha1, results[1].Sha1)
+}
+
+func TestDirSkipsUnreadable(t *testing.T) {
+	if os.Geteuid() == 0 {
+		t.Skip("root can read files without permission")
+	}
+	dir := setupWar(t)
+	secret := filepath.Join(dir, "secret")
+	assert.NoError(t, os.Mkdir(secret, 0000))
+	t.Cleanup(func() { _ = os.Chmod(secret, 0755) })
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(dir, "private.txt"), []byte("hello"), 0000))
+
+	results, err := Dir(dir, Options{})
+
+	assert.NoError(t, err)
+	assert.Equal(t, []string{filepath.Join(dir, "app.war")}, locations(results))
+}
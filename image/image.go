@@ -0,0 +1,377 @@
+//
+// Copyright © 2020-present Sonatype Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+// Package image has functions for hashing the files in the layers of a saved container image tarball
+package image
+
+import (
+	"archive/tar"
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/sonatype-nexus-community/go-sona-types/cyclonedx"
+	"github.com/sonatype-nexus-community/hashbrowns/hasher"
+	"github.com/sonatype-nexus-community/hashbrowns/logger"
+)
+
+const (
+	dockerManifest = "manifest.json"
+	ociIndex       = "index.json"
+	ociBlobsDir    = "blobs/"
+
+	whiteoutPrefix = ".wh."
+	whiteoutOpaque = ".wh..wh..opq"
+
+	// metadataMaxSize is the largest tarball entry kept in memory while looking for manifests and configs
+	metadataMaxSize = 1 << 20
+)
+
+// metadataMaxTotal is the most kept in memory for all of the manifests and configs together, so a tarball of many
+// small files that isn't really an image can't use up all of the memory
+var metadataMaxTotal int64 = 64 << 20
+
+var log = logger.Logger()
+
+// Options configures how the files in an image are hashed
+type Options struct {
+	// ArchiveDepth is passed through to the hasher for archives found in layers
+	ArchiveDepth int
+	// Include decides which files in the image are hashed, by path and size, nil hashes every file
+	Include func(name string, size int64) bool
+}
+
+type dockerManifestEntry struct {
+	Config string   `json:"Config"`
+	Layers []string `json:"Layers"`
+}
+
+type imageConfig struct {
+	RootFS struct {
+		DiffIDs []string `json:"diff_ids"`
+	} `json:"rootfs"`
+}
+
+type ociDescriptor struct {
+	MediaType string `json:"mediaType"`
+	Digest    string `json:"digest"`
+}
+
+type ociIndexDocument struct {
+	Manifests []ociDescriptor `json:"manifests"`
+}
+
+type ociManifest struct {
+	Config ociDescriptor   `json:"config"`
+	Layers []ociDescriptor `json:"layers"`
+}
+
+// layer is a single filesystem layer of an image, identified by where it lives in the tarball
+type layer struct {
+	Path   string
+	Digest string
+}
+
+// layerContents is what was found in a single layer, the files it adds and what it whites out
+type layerContents struct {
+	files     map[string][]cyclonedx.Sha1SBOM
+	whiteouts []string
+	opaques   []string
+}
+
+// Tarball reads an image saved with docker save (or an OCI image layout tarball) at tarPath, and returns the sha1s
+// of the files in its final filesystem, with each location prefixed by the digest of the layer it came from
+func Tarball(tarPath string, opts Options) (sha1s []cyclonedx.Sha1SBOM, err error) {
+	log.WithField("tar", tarPath).Info("Reading image metadata from tarball")
+	metadata, err := readMetadata(tarPath)
+	if err != nil {
+		return
+	}
+
+	layers, err := resolveLayers(metadata)
+	if err != nil {
+		return
+	}
+	log.WithField("layers", layers).Debug("Resolved image layers")
+
+	contents, err := readLayers(tarPath, layers, opts)
+	if err != nil {
+		return
+	}
+
+	return flatten(layers, contents), nil
+}
+
+func readMetadata(tarPath string) (metadata map[string][]byte, err error) {
+	file, err := os.Open(tarPath)
+	if err != nil {
+		return
+	}
+	defer file.Close()
+
+	metadata = map[string][]byte{}
+	var total int64
+	tr := tar.NewReader(file)
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		name := cleanName(header.Name)
+		if !header.FileInfo().Mode().IsRegular() || header.Size > metadataMaxSize || !mightBeMetadata(name) {
+			continue
+		}
+
+		if total += header.Size; total > metadataMaxTotal {
+			return nil, fmt.Errorf("Tarball has more than %d bytes of manifests and configs, is it an image saved with docker save?", metadataMaxTotal)
+		}
+		content, err := ioutil.ReadAll(io.LimitReader(tr, metadataMaxSize))
+		if err != nil {
+			return nil, err
+		}
+		metadata[name] = content
+	}
+
+	return
+}
+
+// mightBeMetadata reports whether the tarball entry called name could be a manifest, index or config, docker save
+// writes them as .json files and OCI image layouts as blobs
+func mightBeMetadata(name string) bool {
+	return strings.HasSuffix(name, ".json") || strings.HasPrefix(name, ociBlobsDir)
+}
+
+func resolveLayers(metadata map[string][]byte) (layers []layer, err error) {
+	if content, ok := metadata[dockerManifest]; ok {
+		var manifest []dockerManifestEntry
+		if err = json.Unmarshal(content, &manifest); err != nil {
+			return nil, fmt.Errorf("Unable to parse %s: %v", dockerManifest, err)
+		}
+		if len(manifest) == 0 {
+			return nil, fmt.Errorf("%s does not list any images", dockerManifest)
+		}
+
+		diffIDs := configDiffIDs(metadata[cleanName(manifest[0].Config)])
+		for i, v := range manifest[0].Layers {
+			l := layer{Path: cleanName(v), Digest: cleanName(v)}
+			if len(diffIDs) == len(manifest[0].Layers) {
+				l.Digest = diffIDs[i]
+			}
+			layers = append(layers, l)
+		}
+		return
+	}
+
+	if content, ok := metadata[ociIndex]; ok {
+		manifest, err := resolveOCIManifest(metadata, content)
+		if err != nil {
+			return nil, err
+		}
+
+		diffIDs := configDiffIDs(metadata[blobPath(manifest.Config.Digest)])
+		for i, v := range manifest.Layers {
+			l := layer{Path: blobPath(v.Digest), Digest: v.Digest}
+			if len(diffIDs) == len(manifest.Layers) {
+				l.Digest = diffIDs[i]
+			}
+			layers = append(layers, l)
+		}
+		return layers, nil
+	}
+
+	return nil, fmt.Errorf("Tarball has neither a %s nor an %s, is it an image saved with docker save?", dockerManifest, ociIndex)
+}
+
+// resolveOCIManifest follows an OCI index (and any nested indexes) to the first image manifest it lists
+func resolveOCIManifest(metadata map[string][]byte, index []byte) (manifest ociManifest, err error) {
+	var doc ociIndexDocument
+	if err = json.Unmarshal(index, &doc); err != nil {
+		return manifest, fmt.Errorf("Unable to parse OCI index: %v", err)
+	}
+	if len(doc.Manifests) == 0 {
+		return manifest, fmt.Errorf("OCI index does not list any manifests")
+	}
+
+	content, ok := metadata[blobPath(doc.Manifests[0].Digest)]
+	if !ok {
+		return manifest, fmt.Errorf("OCI blob %s is missing from tarball", doc.Manifests[0].Digest)
+	}
+	if strings.Contains(doc.Manifests[0].MediaType, "index") || strings.Contains(doc.Manifests[0].MediaType, "manifest.list") {
+		return resolveOCIManifest(metadata, content)
+	}
+
+	if err = json.Unmarshal(content, &manifest); err != nil {
+		return manifest, fmt.Errorf("Unable to parse OCI manifest: %v", err)
+	}
+	return
+}
+
+func configDiffIDs(content []byte) []string {
+	var config imageConfig
+	if len(content) == 0 || json.Unmarshal(content, &config) != nil {
+		return nil
+	}
+	return config.RootFS.DiffIDs
+}
+
+func readLayers(tarPath string, layers []layer, opts Options) (contents map[string]*layerContents, err error) {
+	file, err := os.Open(tarPath)
+	if err != nil {
+		return
+	}
+	defer file.Close()
+
+	wanted := map[string]layer{}
+	for _, v := range layers {
+		wanted[v.Path] = v
+	}
+
+	contents = map[string]*layerContents{}
+	tr := tar.NewReader(file)
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		l, ok := wanted[cleanName(header.Name)]
+		if !ok {
+			continue
+		}
+
+		log.WithField("layer", l.Digest).Info("Hashing files in image layer")
+		c, err := readLayer(l, tr, opts)
+		if err != nil {
+			return nil, fmt.Errorf("Unable to read layer %s: %v", l.Digest, err)
+		}
+		contents[l.Path] = c
+	}
+
+	for _, v := range layers {
+		if _, ok := contents[v.Path]; !ok {
+			return nil, fmt.Errorf("Layer %s is missing from tarball", v.Path)
+		}
+	}
+
+	return
+}
+
+func readLayer(l layer, r io.Reader, opts Options) (contents *layerContents, err error) {
+	br := bufio.NewReader(r)
+	if magic, _ := br.Peek(2); bytes.Equal(magic, []byte{0x1f, 0x8b}) {
+		gz, err := gzip.NewReader(br)
+		if err != nil {
+			return nil, err
+		}
+		defer gz.Close()
+		r = gz
+	} else {
+		r = br
+	}
+
+	contents = &layerContents{files: map[string][]cyclonedx.Sha1SBOM{}}
+	tr := tar.NewReader(r)
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		name := cleanName(header.Name)
+		dir, base := path.Split(name)
+		if base == whiteoutOpaque {
+			contents.opaques = append(contents.opaques, dir)
+			continue
+		}
+		if strings.HasPrefix(base, whiteoutPrefix) {
+			contents.whiteouts = append(contents.whiteouts, dir+strings.TrimPrefix(base, whiteoutPrefix))
+			continue
+		}
+		if !header.FileInfo().Mode().IsRegular() || (opts.Include != nil && !opts.Include(name, header.Size)) {
+			continue
+		}
+
+		hashed, err := hasher.Reader(l.Digest+"/"+name, tr, hasher.Options{ArchiveDepth: opts.ArchiveDepth})
+		if err != nil {
+			return nil, err
+		}
+		contents.files[name] = hashed
+	}
+
+	return
+}
+
+// flatten applies each layer in order, honouring whiteouts, and returns the sha1s of the resulting filesystem
+func flatten(layers []layer, contents map[string]*layerContents) (sha1s []cyclonedx.Sha1SBOM) {
+	filesystem := map[string][]cyclonedx.Sha1SBOM{}
+	for _, l := range layers {
+		c := contents[l.Path]
+		for _, dir := range c.opaques {
+			removeTree(filesystem, dir)
+		}
+		for _, name := range c.whiteouts {
+			delete(filesystem, name)
+			removeTree(filesystem, name+"/")
+		}
+		for name, hashed := range c.files {
+			filesystem[name] = hashed
+		}
+	}
+
+	var names []string
+	for name := range filesystem {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		sha1s = append(sha1s, filesystem[name]...)
+	}
+	return
+}
+
+func removeTree(filesystem map[string][]cyclonedx.Sha1SBOM, dir string) {
+	for name := range filesystem {
+		if strings.HasPrefix(name, dir) {
+			delete(filesystem, name)
+		}
+	}
+}
+
+func blobPath(digest string) string {
+	return ociBlobsDir + strings.Replace(digest, ":", "/", 1)
+}
+
+func cleanName(name string) string {
+	return strings.TrimPrefix(path.Clean("/"+name), "/")
+}
@@ -0,0 +1,179 @@
+//
+// Copyright © 2020-present Sonatype Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+package image
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/sonatype-nexus-community/go-sona-types/cyclonedx"
+	"github.com/stretchr/testify/assert"
+)
+
+const (
+	diffIDOne = "sha256:1111111111111111111111111111111111111111111111111111111111111111"
+	diffIDTwo = "sha256:2222222222222222222222222222222222222222222222222222222222222222"
+	helloSha1 = "aaf4c61ddcc5e8a2dabede0f3b482cd9aea9434d"
+)
+
+type tarEntry struct {
+	name    string
+	content []byte
+}
+
+func tarOf(t *testing.T, entries ...tarEntry) []byte {
+	buf := new(bytes.Buffer)
+	tw := tar.NewWriter(buf)
+	for _, v := range entries {
+		assert.NoError(t, tw.WriteHeader(&tar.Header{Name: v.name, Mode: 0644, Size: int64(len(v.content)), Typeflag: tar.TypeReg}))
+		_, err := tw.Write(v.content)
+		assert.NoError(t, err)
+	}
+	assert.NoError(t, tw.Close())
+	return buf.Bytes()
+}
+
+func gzipOf(t *testing.T, content []byte) []byte {
+	buf := new(bytes.Buffer)
+	gz := gzip.NewWriter(buf)
+	_, err := gz.Write(content)
+	assert.NoError(t, err)
+	assert.NoError(t, gz.Close())
+	return buf.Bytes()
+}
+
+func layerOne(t *testing.T) []byte {
+	return tarOf(t,
+		tarEntry{"usr/lib/old.jar", []byte("old")},
+		tarEntry{"etc/app.conf", []byte("conf")},
+		tarEntry{"opt/app/lib/replaced.so", []byte("replaced")},
+	)
+}
+
+func layerTwo(t *testing.T) []byte {
+	return tarOf(t,
+		tarEntry{"usr/lib/.wh.old.jar", nil},
+		tarEntry{"opt/app/lib/.wh..wh..opq", nil},
+		tarEntry{"opt/app/lib/hello.so", []byte("hello")},
+	)
+}
+
+func writeTarball(t *testing.T, content []byte) string {
+	dir, err := ioutil.TempDir("", "image")
+	assert.NoError(t, err)
+	t.Cleanup(func() { os.RemoveAll(dir) })
+
+	path := filepath.Join(dir, "image.tar")
+	assert.NoError(t, ioutil.WriteFile(path, content, 0644))
+	return path
+}
+
+func locations(sha1s []cyclonedx.Sha1SBOM) (result []string) {
+	for _, v := range sha1s {
+		result = append(result, v.Location)
+	}
+	return
+}
+
+func TestTarballDockerSave(t *testing.T) {
+	path := writeTarball(t, tarOf(t,
+		tarEntry{"manifest.json", []byte(`[{"Config":"abc.json","RepoTags":["app:latest"],"Layers":["one/layer.tar","two/layer.tar"]}]`)},
+		tarEntry{"abc.json", []byte(`{"rootfs":{"type":"layers","diff_ids":["` + diffIDOne + `","` + diffIDTwo + `"]}}`)},
+		tarEntry{"one/layer.tar", layerOne(t)},
+		tarEntry{"two/layer.tar", layerTwo(t)},
+	))
+
+	results, err := Tarball(path, Options{})
+
+	assert.NoError(t, err)
+	assert.Equal(t, []string{diffIDOne + "/etc/app.conf", diffIDTwo + "/opt/app/lib/hello.so"}, locations(results))
+	assert.Equal(t, helloSha1, results[1].Sha1)
+}
+
+func TestTarballInclude(t *testing.T) {
+	path := writeTarball(t, tarOf(t,
+		tarEntry{"manifest.json", []byte(`[{"Config":"abc.json","Layers":["one/layer.tar","two/layer.tar"]}]`)},
+		tarEntry{"abc.json", []byte(`{"rootfs":{"type":"layers","diff_ids":["` + diffIDOne + `","` + diffIDTwo + `"]}}`)},
+		tarEntry{"one/layer.tar", layerOne(t)},
+		tarEntry{"two/layer.tar", layerTwo(t)},
+	))
+
+	results, err := Tarball(path, Options{Include: func(name string, size int64) bool {
+		return (strings.HasSuffix(name, ".so") || strings.HasSuffix(name, ".conf")) && size > 4
+	}})
+
+	assert.NoError(t, err)
+	assert.Equal(t, []string{diffIDTwo + "/opt/app/lib/hello.so"}, locations(results))
+}
+
+func TestTarballOCILayout(t *testing.T) {
+	path := writeTarball(t, tarOf(t,
+		tarEntry{"oci-layout", []byte(`{"imageLayoutVersion":"1.0.0"}`)},
+		tarEntry{"index.json", []byte(`{"manifests":[{"mediaType":"application/vnd.oci.image.manifest.v1+json","digest":"sha256:aaaa"}]}`)},
+		tarEntry{"blobs/sha256/aaaa", []byte(`{"config":{"digest":"sha256:bbbb"},"layers":[{"digest":"sha256:cccc"},{"digest":"sha256:dddd"}]}`)},
+		tarEntry{"blobs/sha256/bbbb", []byte(`{"rootfs":{"type":"layers","diff_ids":["` + diffIDOne + `","` + diffIDTwo + `"]}}`)},
+		tarEntry{"blobs/sha256/cccc", gzipOf(t, layerOne(t))},
+		tarEntry{"blobs/sha256/dddd", layerTwo(t)},
+	))
+
+	results, err := Tarball(path, Options{})
+
+	assert.NoError(t, err)
+	assert.Equal(t, []string{diffIDOne + "/etc/app.conf", diffIDTwo + "/opt/app/lib/hello.so"}, locations(results))
+}
+
+func TestTarballNotAnImage(t *testing.T) {
+	path := writeTarball(t, tarOf(t, tarEntry{"hello.txt", []byte("hello")}))
+
+	results, err := Tarball(path, Options{})
+
+	assert.Nil(t, results)
+	assert.NotNil(t, err)
+}
+
+func TestTarballMissingLayer(t *testing.T) {
+	path := writeTarball(t, tarOf(t,
+		tarEntry{"manifest.json", []byte(`[{"Config":"abc.json","Layers":["one/layer.tar"]}]`)},
+	))
+
+	results, err := Tarball(path, Options{})
+
+	assert.Nil(t, results)
+	assert.Equal(t, "Layer one/layer.tar is missing from tarball", err.Error())
+}
+
+func TestTarballTooMuchMetadata(t *testing.T) {
+	origMetadataMaxTotal := metadataMaxTotal
+	t.Cleanup(func() { metadataMaxTotal = origMetadataMaxTotal })
+	metadataMaxTotal = 64
+
+	path := writeTarball(t, tarOf(t,
+		tarEntry{"manifest.json", []byte(`[{"Config":"abc.json","Layers":["one/layer.tar"]}]`)},
+		tarEntry{"abc.json", []byte(`{"rootfs":{"type":"layers","diff_ids":["` + diffIDOne + `"]}}`)},
+		tarEntry{"one/layer.tar", layerOne(t)},
+	))
+
+	results, err := Tarball(path, Options{})
+
+	assert.Nil(t, results)
+	assert.Equal(t, "Tarball has more than 64 bytes of manifests and configs, is it an image saved with docker save?", err.Error())
+}
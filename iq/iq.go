@@ -23,10 +23,13 @@ import (
 	"fmt"
 	"io/ioutil"
 	"net/http"
+	"os"
+	"strings"
 	"time"
 
 	"github.com/sirupsen/logrus"
 	"github.com/sonatype-nexus-community/hashbrowns/logger"
+	"github.com/sonatype-nexus-community/hashbrowns/progress"
 	hashtypes "github.com/sonatype-nexus-community/hashbrowns/types"
 	"github.com/sonatype-nexus-community/nancy/types"
 	useragent "github.com/sonatype-nexus-community/nancy/useragent"
@@ -44,11 +47,6 @@ const (
 	pollInterval = 1 * time.Second
 )
 
-var (
-	localConfig *hashtypes.Config
-	tries       = 0
-)
-
 // Internal types for use by this package, don't need to expose them
 type applicationResponse struct {
 	Applications []application `json:"applications"`
@@ -62,73 +60,104 @@ type thirdPartyAPIResult struct {
 	StatusURL string `json:"statusUrl"`
 }
 
-var statusURLResp types.StatusURLResult
-
-var log *logrus.Logger
+// StatusResult is the result of polling Nexus IQ Server, with where to find the data behind the report
+type StatusResult struct {
+	types.StatusURLResult
+	ReportDataURL string `json:"reportDataUrl"`
+}
 
-// AuditPackages accepts a slice of purls, public application ID, and configuration, and will submit these to
-// Nexus IQ Server for audit, and return a struct of StatusURLResult
-func AuditPackages(sbom string, config *hashtypes.Config) (types.StatusURLResult, error) {
-	log = logger.GetLogger("", config.LogLevel)
+// audit holds the state of a single submission to Nexus IQ Server, so that several can run at once
+type audit struct {
+	config *hashtypes.Config
+	log    *logrus.Logger
+	tries  int
+}
 
+func init() {
 	useragent.CLIENTTOOL = "hashbrowns-client"
-	log.WithField("client", useragent.CLIENTTOOL).Trace("Setting the user agent")
+}
 
-	localConfig = config
+// AuditPackages accepts an SBOM and configuration, and will submit these to Nexus IQ Server for audit, and return a
+// struct of StatusResult
+func AuditPackages(sbom string, config *hashtypes.Config) (statusURLResp StatusResult, err error) {
+	a := &audit{config: config, log: logger.GetLogger(config.LogLevel)}
+	log := a.log
 
-	if localConfig.User == "admin" && localConfig.Token == "admin123" {
+	log.WithField("client", useragent.CLIENTTOOL).Trace("Using the user agent")
+
+	if config.User == "admin" && config.Token == "admin123" {
 		log.Trace("Warning user of bad life choices, default Nexus IQ Server user and password")
 		warnUserOfBadLifeChoices()
 	}
 
 	log.WithField("application_id", config.Application).Debug("Getting internal application ID from Nexus IQ Server")
-	internalID, err := getInternalApplicationID(config.Application)
+	internalID, err := a.getInternalApplicationID(config.Application)
 	if internalID == "" && err != nil {
 		log.WithField("error", err).Error("Unable to obtain internal application ID from Nexus IQ Server")
 		return statusURLResp, err
 	}
 
+	components := strings.Count(sbom, "<component ")
 	log.WithFields(logrus.Fields{
-		"internal_id": internalID,
-		"sbom":        sbom,
-	}).Debug("Submitting SBOM to Nexus IQ Server")
-	statusURL, err := submitToThirdPartyAPI(sbom, internalID)
+		"internal_id":     internalID,
+		"sbom_components": components,
+	}).WithFields(logger.Payload("sbom", sbom)).Debug("Submitting SBOM to Nexus IQ Server")
+	size := progress.Bytes(int64(len(sbom)))
+	upload := progress.Start("Submitting SBOM to Nexus IQ Server")
+	upload.Update("uploading %s", size)
+	statusURL, err := a.submitToThirdPartyAPI(sbom, internalID)
 	if statusURL == "" || err != nil {
+		upload.Done("failed")
+		if err == nil {
+			err = fmt.Errorf("no status URL was returned")
+		}
 		log.WithFields(logrus.Fields{
-			"error": err,
-			"sbom":  sbom,
-		}).Error("Unable to submit sbom to Nexus IQ Server")
+			"error":           err,
+			"sbom_components": components,
+		}).WithFields(logger.Payload("sbom", sbom)).Error("Unable to submit sbom to Nexus IQ Server")
 
-		return statusURLResp, fmt.Errorf("There was an issue submitting your sbom to the Nexus IQ Third Party API, sbom: %s", sbom)
+		return statusURLResp, fmt.Errorf("There was an issue submitting your sbom of %d components (%s) to the Nexus IQ Third Party API: %v", components, size, err)
 	}
+	upload.Done("sent %s", size)
 	log.WithField("status_url", statusURL).Trace("Obtained StatusURL from Nexus IQ Server")
 
-	statusURLResp = types.StatusURLResult{}
-
-	finished := make(chan bool)
-
-	go func() {
-		for {
-			select {
-			case <-finished:
-				return
-			default:
-				log.WithField("status_url", statusURL).Trace("Polling Nexus IQ Server for response")
-				pollIQServer(fmt.Sprintf("%s/%s", localConfig.Server, statusURL), finished, localConfig.MaxRetries)
-				time.Sleep(pollInterval)
-			}
+	start := time.Now()
+	timeout := time.Duration(config.MaxRetries) * pollInterval
+	wait := progress.Start("Waiting for Nexus IQ Server to evaluate policy")
+	for {
+		log.WithField("status_url", statusURL).Trace("Polling Nexus IQ Server for response")
+		var finished bool
+		statusURLResp, finished, err = a.pollIQServer(fmt.Sprintf("%s/%s", config.Server, statusURL))
+		if err != nil {
+			wait.Done("failed")
+			return
 		}
-	}()
+		if finished {
+			wait.Done("done")
+			return
+		}
+		wait.Update("%s elapsed of %s timeout", time.Since(start).Round(time.Second), timeout)
+		time.Sleep(pollInterval)
+	}
+}
 
-	<-finished
-	return statusURLResp, nil
+// InternalApplicationID looks up the internal ID of config.Application on Nexus IQ Server, without submitting anything
+func InternalApplicationID(config *hashtypes.Config) (string, error) {
+	a := &audit{config: config, log: logger.GetLogger(config.LogLevel)}
+	return a.getInternalApplicationID(config.Application)
 }
 
-func getInternalApplicationID(applicationID string) (string, error) {
+// SubmitURL returns the URL that SBOMs for the application with internalID are submitted to for stage
+func SubmitURL(server string, internalID string, stage string) string {
+	return fmt.Sprintf("%s%s%s%s%s", server, thirdPartyAPILeft, internalID, thirdPartyAPIRight, stage)
+}
+
+func (a *audit) getInternalApplicationID(applicationID string) (string, error) {
+	log := a.log
 	log.WithField("application_id", applicationID).Debug("Beginning to obtain internal application ID from Nexus IQ Server")
 	client := &http.Client{}
 
-	url := fmt.Sprintf("%s%s%s", localConfig.Server, internalApplicationIDURL, applicationID)
+	url := fmt.Sprintf("%s%s%s", a.config.Server, internalApplicationIDURL, applicationID)
 
 	log.WithFields(logrus.Fields{
 		"url": url,
@@ -147,7 +176,7 @@ func getInternalApplicationID(applicationID string) (string, error) {
 	}
 
 	log.Info("Setting up basic auth, and getting user agent for request to Nexus IQ Server for internal application ID")
-	req.SetBasicAuth(localConfig.User, localConfig.Token)
+	req.SetBasicAuth(a.config.User, a.config.Token)
 	req.Header.Set("User-Agent", useragent.GetUserAgent())
 	log.WithFields(logrus.Fields{
 		"user_agent": useragent.GetUserAgent(),
@@ -177,7 +206,7 @@ func getInternalApplicationID(applicationID string) (string, error) {
 
 			return "", err
 		}
-		log.WithField("body_bytes", string(bodyBytes)).Trace("Obtained a response body from Nexus IQ Server for internal application ID")
+		log.WithFields(logger.Payload("body", string(bodyBytes))).Trace("Obtained a response body from Nexus IQ Server for internal application ID")
 
 		log.Info("Attempting to unmarshal response from Nexus IQ Server")
 		var response applicationResponse
@@ -205,14 +234,12 @@ func getInternalApplicationID(applicationID string) (string, error) {
 	return "", fmt.Errorf("Unable to communicate with Nexus IQ Server, status code returned is: %d", resp.StatusCode)
 }
 
-func submitToThirdPartyAPI(sbom string, internalID string) (string, error) {
-	log.WithFields(logrus.Fields{
-		"internal_application_id": internalID,
-		"sbom":                    sbom,
-	}).Debug("Beginning to submit SBOM to Nexus IQ Server")
+func (a *audit) submitToThirdPartyAPI(sbom string, internalID string) (string, error) {
+	log := a.log
+	log.WithField("internal_application_id", internalID).WithFields(logger.Payload("sbom", sbom)).Debug("Beginning to submit SBOM to Nexus IQ Server")
 	client := &http.Client{}
 
-	url := fmt.Sprintf("%s%s", localConfig.Server, fmt.Sprintf("%s%s%s%s", thirdPartyAPILeft, internalID, thirdPartyAPIRight, localConfig.Stage))
+	url := SubmitURL(a.config.Server, internalID, a.config.Stage)
 
 	log.WithFields(logrus.Fields{
 		"url": url,
@@ -231,7 +258,7 @@ func submitToThirdPartyAPI(sbom string, internalID string) (string, error) {
 	}
 
 	log.Info("Setting up basic auth, getting user agent, and setting content type for request to Nexus IQ Server for submitting SBOM")
-	req.SetBasicAuth(localConfig.User, localConfig.Token)
+	req.SetBasicAuth(a.config.User, a.config.Token)
 	req.Header.Set("User-Agent", useragent.GetUserAgent())
 	req.Header.Set("Content-Type", contentTypeApplicationXML)
 	log.WithFields(logrus.Fields{
@@ -262,7 +289,7 @@ func submitToThirdPartyAPI(sbom string, internalID string) (string, error) {
 
 			return "", err
 		}
-		log.WithField("body_bytes", string(bodyBytes)).Trace("Obtained a response body from Nexus IQ Server for submitting SBOM")
+		log.WithFields(logger.Payload("body", string(bodyBytes))).Trace("Obtained a response body from Nexus IQ Server for submitting SBOM")
 
 		log.Info("Attempting to unmarshal response from Nexus IQ Server for submitting SBOM")
 		var response thirdPartyAPIResult
@@ -280,18 +307,22 @@ func submitToThirdPartyAPI(sbom string, internalID string) (string, error) {
 	}
 
 	log.WithField("status_code", resp.StatusCode).Error("Unable to submit SBOM to Nexus IQ Server")
-	return "", nil
+	return "", fmt.Errorf("Unable to communicate with Nexus IQ Server, status code returned is: %d", resp.StatusCode)
 }
 
-func pollIQServer(statusURL string, finished chan bool, maxRetries int) {
+// pollIQServer checks statusURL once, and reports whether polling is finished because results are in. Once it has
+// been tried too many times, it returns an error.
+func (a *audit) pollIQServer(statusURL string) (statusURLResp StatusResult, finished bool, err error) {
+	log := a.log
+	maxRetries := a.config.MaxRetries
 	log.WithFields(logrus.Fields{
 		"status_url":  statusURL,
-		"tries":       tries,
+		"tries":       a.tries,
 		"max_retries": maxRetries,
 	}).Trace("Beginning a poll of Nexus IQ Server for results")
-	if tries > maxRetries {
-		log.WithField("max_retries", maxRetries).Info("Max tries exceeded, shutting down polling of Nexus IQ Server")
-		finished <- true
+	if a.tries > maxRetries {
+		log.WithField("max_retries", maxRetries).Error("Max tries exceeded, shutting down polling of Nexus IQ Server")
+		return statusURLResp, true, fmt.Errorf("Nexus IQ Server did not finish evaluating policy after %d tries, try again or raise --max-retries", maxRetries)
 	}
 
 	client := &http.Client{}
@@ -299,11 +330,11 @@ func pollIQServer(statusURL string, finished chan bool, maxRetries int) {
 	req, err := http.NewRequest("GET", statusURL, nil)
 	if err != nil {
 		log.WithField("error", err).Error("Unable to setup request to poll Nexus IQ Server for results")
-		finished <- true
+		return
 	}
 
 	log.Info("Setting up basic auth, and getting user agent for poll request to Nexus IQ Server for results")
-	req.SetBasicAuth(localConfig.User, localConfig.Token)
+	req.SetBasicAuth(a.config.User, a.config.Token)
 	req.Header.Set("User-Agent", useragent.GetUserAgent())
 	log.WithFields(logrus.Fields{
 		"user_agent": useragent.GetUserAgent(),
@@ -313,7 +344,7 @@ func pollIQServer(statusURL string, finished chan bool, maxRetries int) {
 	resp, err := client.Do(req)
 	if err != nil {
 		log.WithField("error", err).Error("Unable to do request to poll Nexus IQ Server for results")
-		finished <- true
+		return
 	}
 
 	defer resp.Body.Close()
@@ -327,39 +358,42 @@ func pollIQServer(statusURL string, finished chan bool, maxRetries int) {
 				"error": err,
 			}).Error("Unable to read response body from polling Nexus IQ Server for results")
 
-			panic(err)
+			return statusURLResp, true, err
 		}
-		log.WithField("body_bytes", string(bodyBytes)).Trace("Obtained a response body from polling Nexus IQ Server for results")
+		log.WithFields(logger.Payload("body", string(bodyBytes))).Trace("Obtained a response body from polling Nexus IQ Server for results")
 
 		log.Info("Attempting to unmarshal response from polling Nexus IQ Server for results")
-		var response types.StatusURLResult
-		err = json.Unmarshal(bodyBytes, &response)
+		err = json.Unmarshal(bodyBytes, &statusURLResp)
 		if err != nil {
 			log.WithFields(logrus.Fields{
 				"error": err,
 			}).Error("Unable to unmarshal response body from polling Nexus IQ Server for results")
 
-			panic(err)
+			return statusURLResp, true, err
 		}
-		log.WithField("response", response).Trace("Successfully unmarshal'd response from polling Nexus IQ Server for results, returning")
+		log.WithField("response", statusURLResp).Trace("Successfully unmarshal'd response from polling Nexus IQ Server for results, returning")
 
-		statusURLResp = response
-		if response.IsError {
-			log.WithField("response", response).Error("Nexus IQ Server responded with an error (but valid request) for report")
-			finished <- true
+		if statusURLResp.IsError {
+			log.WithField("response", statusURLResp).Error("Nexus IQ Server responded with an error (but valid request) for report")
 		}
-		finished <- true
+		return statusURLResp, true, nil
+	}
+	if resp.StatusCode != http.StatusNotFound {
+		log.WithField("status_code", resp.StatusCode).Error("Nexus IQ Server gave an unexpected response to polling for results")
+
+		return statusURLResp, true, fmt.Errorf("Unable to poll Nexus IQ Server for results, status code returned is: %d", resp.StatusCode)
 	}
 	log.Info("Nexus IQ Server gave a 404 response to polling, incrementing tries and moving forward")
-	tries++
-	fmt.Print(".")
+	a.tries++
+
+	return
 }
 
 func warnUserOfBadLifeChoices() {
-	fmt.Println()
-	fmt.Println("!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!")
-	fmt.Println("!!!! WARNING : You are using the default username and password for Nexus IQ. !!!!")
-	fmt.Println("!!!! You are strongly encouraged to change these, and use a token.           !!!!")
-	fmt.Println("!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!")
-	fmt.Println()
+	fmt.Fprintln(os.Stderr)
+	fmt.Fprintln(os.Stderr, "!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!")
+	fmt.Fprintln(os.Stderr, "!!!! WARNING : You are using the default username and password for Nexus IQ. !!!!")
+	fmt.Fprintln(os.Stderr, "!!!! You are strongly encouraged to change these, and use a token.           !!!!")
+	fmt.Fprintln(os.Stderr, "!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!")
+	fmt.Fprintln(os.Stderr)
 }
@@ -0,0 +1,116 @@
+//
+// Copyright © 2020-present Sonatype Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+package iq
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+	"github.com/sonatype-nexus-community/hashbrowns/logger"
+	"github.com/sonatype-nexus-community/hashbrowns/report"
+	hashtypes "github.com/sonatype-nexus-community/hashbrowns/types"
+	useragent "github.com/sonatype-nexus-community/nancy/useragent"
+)
+
+type policyReport struct {
+	Components []policyComponent `json:"components"`
+}
+
+type policyComponent struct {
+	Hash        string            `json:"hash"`
+	DisplayName string            `json:"displayName"`
+	Pathnames   []string          `json:"pathnames"`
+	Violations  []policyViolation `json:"violations"`
+}
+
+type policyViolation struct {
+	PolicyName        string `json:"policyName"`
+	PolicyThreatLevel int    `json:"policyThreatLevel"`
+	Waived            bool   `json:"waived"`
+}
+
+// PolicyViolations fetches the policy report behind reportDataURL (as returned in StatusResult), and returns the
+// violations in it that have not been waived. The policy report doesn't say which policy action each violation had,
+// so these include any that were only warnings, not just the ones that failed the audit.
+func PolicyViolations(reportDataURL string, config *hashtypes.Config) (violations []report.Violation, err error) {
+	log := logger.GetLogger(config.LogLevel)
+
+	url := fmt.Sprintf("%s/%s", config.Server, strings.TrimSuffix(reportDataURL, "/raw")+"/policy")
+	log.WithField("url", url).Debug("Beginning to obtain policy report from Nexus IQ Server")
+
+	req, err := http.NewRequest("GET", url, nil)
+	if err != nil {
+		return
+	}
+	req.SetBasicAuth(config.User, config.Token)
+	req.Header.Set("User-Agent", useragent.GetUserAgent())
+
+	resp, err := (&http.Client{}).Do(req)
+	if err != nil {
+		return
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("Unable to obtain policy report from Nexus IQ Server, status code returned is: %d", resp.StatusCode)
+	}
+
+	var policy policyReport
+	if err = json.NewDecoder(resp.Body).Decode(&policy); err != nil {
+		return
+	}
+	log.WithField("components", len(policy.Components)).Trace("Obtained policy report from Nexus IQ Server")
+
+	for _, c := range policy.Components {
+		location := c.DisplayName
+		if len(c.Pathnames) > 0 {
+			location = c.Pathnames[0]
+		}
+		for _, v := range c.Violations {
+			if v.Waived {
+				continue
+			}
+			violations = append(violations, report.Violation{
+				Sha1:        c.Hash,
+				Location:    location,
+				Reason:      v.PolicyName,
+				Severity:    threatLevelSeverity(v.PolicyThreatLevel),
+				ThreatLevel: v.PolicyThreatLevel,
+			})
+		}
+	}
+	log.WithFields(logrus.Fields{
+		"violations": len(violations),
+	}).Debug("Obtained policy violations from Nexus IQ Server")
+
+	return
+}
+
+// threatLevelSeverity turns a Nexus IQ Server threat level into the same words used by local deny lists
+func threatLevelSeverity(level int) string {
+	switch {
+	case level >= 8:
+		return "critical"
+	case level >= 4:
+		return "high"
+	case level >= 2:
+		return "medium"
+	}
+	return "low"
+}
@@ -0,0 +1,184 @@
+//
+// Copyright © 2020-present Sonatype Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+// Package local evaluates sha1s against lists of known bad (and known good) hashes, without Nexus IQ Server
+package local
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/sonatype-nexus-community/go-sona-types/cyclonedx"
+	"github.com/sonatype-nexus-community/hashbrowns/report"
+)
+
+// DefaultSeverity is used for list entries that don't have a severity
+const DefaultSeverity = "high"
+
+// Entry is a single hash in a deny or allow list, and why it is there
+type Entry struct {
+	Hash     string `json:"hash"`
+	Sha1     string `json:"sha1"`
+	Reason   string `json:"reason"`
+	Severity string `json:"severity"`
+}
+
+// List is a deny or allow list, keyed by lower case sha1
+type List map[string]Entry
+
+// LoadLists reads each of paths and merges them into one list, later files winning for hashes listed more than once
+func LoadLists(paths []string) (list List, err error) {
+	list = List{}
+	for _, v := range paths {
+		if err = list.load(v); err != nil {
+			return nil, err
+		}
+	}
+	return
+}
+
+// load reads a list file, a .csv or .json file by extension, or otherwise a text file with a hash per line
+func (l List) load(path string) (err error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return
+	}
+	defer file.Close()
+
+	var entries []Entry
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".csv":
+		entries, err = parseCSV(file)
+	case ".json":
+		err = json.NewDecoder(file).Decode(&entries)
+	default:
+		entries, err = parseText(file)
+	}
+	if err != nil {
+		return fmt.Errorf("Unable to parse list %s: %v", path, err)
+	}
+
+	for i, v := range entries {
+		if v.Hash == "" {
+			v.Hash = v.Sha1
+		}
+		if v.Hash == "" {
+			return fmt.Errorf("Entry %d in list %s has no hash", i+1, path)
+		}
+		v.Hash = strings.ToLower(v.Hash)
+		v.Sha1 = ""
+		if v.Severity == "" {
+			v.Severity = DefaultSeverity
+		}
+		l[v.Hash] = v
+	}
+
+	return
+}
+
+// parseText reads a hash per line, optionally followed by the reason it is listed, skipping blank and # comment lines
+func parseText(r io.Reader) (entries []Entry, err error) {
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		fields := strings.SplitN(line, " ", 2)
+		entry := Entry{Hash: fields[0]}
+		if len(fields) == 2 {
+			entry.Reason = strings.TrimSpace(fields[1])
+		}
+		entries = append(entries, entry)
+	}
+	return entries, scanner.Err()
+}
+
+// parseCSV reads a CSV file with a header naming the hash (or sha1), reason and severity columns
+func parseCSV(r io.Reader) (entries []Entry, err error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, fmt.Errorf("Unable to read CSV header: %v", err)
+	}
+	columns := map[string]int{}
+	for i, v := range header {
+		columns[strings.ToLower(strings.TrimSpace(v))] = i
+	}
+	if _, ok := columns["hash"]; !ok {
+		if i, ok := columns["sha1"]; ok {
+			columns["hash"] = i
+		} else {
+			return nil, fmt.Errorf("CSV header does not have a hash or sha1 column")
+		}
+	}
+
+	column := func(record []string, name string) string {
+		if i, ok := columns[name]; ok && i < len(record) {
+			return record[i]
+		}
+		return ""
+	}
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		if column(record, "hash") == "" {
+			continue
+		}
+		entries = append(entries, Entry{
+			Hash:     column(record, "hash"),
+			Reason:   column(record, "reason"),
+			Severity: column(record, "severity"),
+		})
+	}
+	return
+}
+
+// Evaluate checks each of sha1s against deny, returning a violation for every match that is not also in allow
+func Evaluate(sha1s []cyclonedx.Sha1SBOM, deny List, allow List) (violations []report.Violation) {
+	for _, v := range sha1s {
+		hash := strings.ToLower(v.Sha1)
+		entry, denied := deny[hash]
+		if !denied {
+			continue
+		}
+		if _, allowed := allow[hash]; allowed {
+			continue
+		}
+		violations = append(violations, report.Violation{
+			Sha1:     v.Sha1,
+			Location: v.Location,
+			Reason:   entry.Reason,
+			Severity: entry.Severity,
+		})
+	}
+	return
+}
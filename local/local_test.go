@@ -0,0 +1,75 @@
+//
+// Copyright © 2020-present Sonatype Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+package local
+
+import (
+	"testing"
+
+	"github.com/sonatype-nexus-community/go-sona-types/cyclonedx"
+	"github.com/sonatype-nexus-community/hashbrowns/report"
+	"github.com/stretchr/testify/assert"
+)
+
+var entries = []cyclonedx.Sha1SBOM{
+	{Sha1: "9987ca4f73d5ea0e534dfbf19238552df4de507e", Location: "lib/foo.jar"},
+	{Sha1: "2a72a07fbc9de22308d12a32f7d33504349e63c9", Location: "lib/bar.jar"},
+	{Sha1: "aaf4c61ddcc5e8a2dabede0f3b482cd9aea9434d", Location: "lib/hello.so"},
+}
+
+func TestLoadListsText(t *testing.T) {
+	list, err := LoadLists([]string{"testdata/deny.txt"})
+
+	assert.NoError(t, err)
+	assert.Equal(t, List{
+		"9987ca4f73d5ea0e534dfbf19238552df4de507e": {Hash: "9987ca4f73d5ea0e534dfbf19238552df4de507e", Reason: "Backdoored build of foo", Severity: "high"},
+		"da39a3ee5e6b4b0d3255bfef95601890afd80709": {Hash: "da39a3ee5e6b4b0d3255bfef95601890afd80709", Severity: "high"},
+	}, list)
+}
+
+func TestLoadListsCSVAndJSON(t *testing.T) {
+	expected := List{
+		"9987ca4f73d5ea0e534dfbf19238552df4de507e": {Hash: "9987ca4f73d5ea0e534dfbf19238552df4de507e", Reason: "Backdoored build of foo", Severity: "critical"},
+		"2a72a07fbc9de22308d12a32f7d33504349e63c9": {Hash: "2a72a07fbc9de22308d12a32f7d33504349e63c9", Reason: "Cryptominer", Severity: "high"},
+	}
+
+	list, err := LoadLists([]string{"testdata/deny.csv"})
+	assert.NoError(t, err)
+	assert.Equal(t, expected, list)
+
+	list, err = LoadLists([]string{"testdata/deny.json"})
+	assert.NoError(t, err)
+	assert.Equal(t, expected, list)
+}
+
+func TestLoadListsMissing(t *testing.T) {
+	list, err := LoadLists([]string{"testdata/missing.txt"})
+
+	assert.Nil(t, list)
+	assert.NotNil(t, err)
+}
+
+func TestEvaluate(t *testing.T) {
+	deny, err := LoadLists([]string{"testdata/deny.csv"})
+	assert.NoError(t, err)
+	allow, err := LoadLists([]string{"testdata/allow.txt"})
+	assert.NoError(t, err)
+
+	violations := Evaluate(entries, deny, allow)
+
+	assert.Equal(t, []report.Violation{
+		{Sha1: "9987ca4f73d5ea0e534dfbf19238552df4de507e", Location: "lib/foo.jar", Reason: "Backdoored build of foo", Severity: "critical"},
+	}, violations)
+}
@@ -0,0 +1,200 @@
+//
+// Copyright © 2020-present Sonatype Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+// Package location has functions for tidying up the locations of sha1s before they are submitted, so build agent
+// specific or internal paths don't end up in reports
+package location
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+	"github.com/sonatype-nexus-community/go-sona-types/cyclonedx"
+	"github.com/sonatype-nexus-community/hashbrowns/logger"
+)
+
+// The ways locations can be redacted, as passed to --redact-paths
+const (
+	RedactBasename = "basename"
+	RedactHash     = "hash"
+)
+
+// hashLength is how many hex characters of the sha256 of a location are kept when redacting by hash
+const hashLength = 16
+
+var log = logger.Logger()
+
+// Options configures how locations are changed. Entries with a location matching an exclude glob are dropped, then
+// prefixes are stripped, rewrites applied in order, and finally the location is redacted (if set).
+type Options struct {
+	Excludes      []string
+	StripPrefixes []string
+	Rewrites      []string
+	Redact        string
+}
+
+type rewrite struct {
+	pattern     *regexp.Regexp
+	replacement string
+}
+
+// RedactModes returns the names of every redaction mode
+func RedactModes() []string {
+	return []string{RedactBasename, RedactHash}
+}
+
+// Enabled reports whether opts would change anything
+func (opts Options) Enabled() bool {
+	return len(opts.Excludes) > 0 || len(opts.StripPrefixes) > 0 || len(opts.Rewrites) > 0 || opts.Redact != ""
+}
+
+// Validate returns an error if opts has a glob or rewrite that can't be parsed, or an unknown redaction mode
+func (opts Options) Validate() error {
+	_, _, err := opts.compile()
+	return err
+}
+
+func (opts Options) compile() (excludes []*regexp.Regexp, rewrites []rewrite, err error) {
+	for _, v := range opts.Excludes {
+		re, err := Glob(v)
+		if err != nil {
+			return nil, nil, fmt.Errorf("Unable to parse exclude %q: %v", v, err)
+		}
+		excludes = append(excludes, re)
+	}
+
+	for _, v := range opts.Rewrites {
+		parts := strings.SplitN(v, "=", 2)
+		if len(parts) != 2 || parts[0] == "" {
+			return nil, nil, fmt.Errorf("Unable to parse path rewrite %q, expected regex=replacement", v)
+		}
+		re, err := regexp.Compile(parts[0])
+		if err != nil {
+			return nil, nil, fmt.Errorf("Unable to parse path rewrite %q: %v", v, err)
+		}
+		rewrites = append(rewrites, rewrite{pattern: re, replacement: parts[1]})
+	}
+
+	switch opts.Redact {
+	case "", RedactBasename, RedactHash:
+	default:
+		return nil, nil, fmt.Errorf("Unknown path redaction %q, supported redactions are: %s", opts.Redact, strings.Join(RedactModes(), ", "))
+	}
+
+	return
+}
+
+// Glob turns a path glob into a regular expression, where ** matches across directories, * and ? match within one,
+// and a glob without a / matches the file name anywhere
+func Glob(glob string) (*regexp.Regexp, error) {
+	var b strings.Builder
+	b.WriteString("^")
+	if !strings.Contains(glob, "/") {
+		b.WriteString("(.*/)?")
+	}
+	for i := 0; i < len(glob); i++ {
+		switch c := glob[i]; c {
+		case '*':
+			if i+1 < len(glob) && glob[i+1] == '*' {
+				b.WriteString(".*")
+				i++
+			} else {
+				b.WriteString("[^/]*")
+			}
+		case '?':
+			b.WriteString("[^/]")
+		default:
+			b.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+	b.WriteString("$")
+	return regexp.Compile(b.String())
+}
+
+func redact(location string, mode string) string {
+	switch mode {
+	case RedactBasename:
+		// Locations inside archives look like app.war!/WEB-INF/lib/foo.jar, and may come from Windows
+		if i := strings.LastIndexAny(location, `/\!`); i >= 0 {
+			return location[i+1:]
+		}
+	case RedactHash:
+		sum := sha256.Sum256([]byte(location))
+		return hex.EncodeToString(sum[:])[:hashLength]
+	}
+	return location
+}
+
+// Redact returns sha1s with their locations redacted as mode says, one of RedactModes or empty to leave them be
+func Redact(sha1s []cyclonedx.Sha1SBOM, mode string) (redacted []cyclonedx.Sha1SBOM) {
+	if mode == "" {
+		return sha1s
+	}
+	for _, v := range sha1s {
+		v.Location = redact(v.Location, mode)
+		redacted = append(redacted, v)
+	}
+	return
+}
+
+// Apply returns the sha1s that aren't excluded by opts with their locations changed, and how many were excluded
+func Apply(sha1s []cyclonedx.Sha1SBOM, opts Options) (kept []cyclonedx.Sha1SBOM, excluded int, err error) {
+	excludes, rewrites, err := opts.compile()
+	if err != nil {
+		return
+	}
+
+	for _, v := range sha1s {
+		if matchesAny(excludes, v.Location) {
+			log.WithField("sha1", v).Trace("Excluded sha1")
+			excluded++
+			continue
+		}
+
+		for _, prefix := range opts.StripPrefixes {
+			if strings.HasPrefix(v.Location, prefix) {
+				v.Location = strings.TrimPrefix(v.Location, prefix)
+				break
+			}
+		}
+		for _, r := range rewrites {
+			v.Location = r.pattern.ReplaceAllString(v.Location, r.replacement)
+		}
+		v.Location = redact(v.Location, opts.Redact)
+
+		kept = append(kept, v)
+	}
+
+	log.WithFields(logrus.Fields{
+		"kept":     len(kept),
+		"excluded": excluded,
+	}).Debug("Finished changing locations of sha1s")
+
+	return
+}
+
+func matchesAny(patterns []*regexp.Regexp, location string) bool {
+	for _, re := range patterns {
+		if re.MatchString(location) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,106 @@
+//
+// Copyright © 2020-present Sonatype Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+package location
+
+import (
+	"testing"
+
+	"github.com/sonatype-nexus-community/go-sona-types/cyclonedx"
+	"github.com/stretchr/testify/assert"
+)
+
+var entries = []cyclonedx.Sha1SBOM{
+	{Sha1: "1", Location: "/var/lib/jenkins/workspace/job-123/lib/foo.jar"},
+	{Sha1: "2", Location: "/var/lib/jenkins/workspace/job-123/test/fixtures/bar.jar"},
+	{Sha1: "3", Location: "app.war!/WEB-INF/lib/baz.jar"},
+	{Sha1: "4", Location: `C:\build\lib\qux.dll`},
+}
+
+func locations(results []cyclonedx.Sha1SBOM) (result []string) {
+	for _, v := range results {
+		result = append(result, v.Location)
+	}
+	return
+}
+
+func TestApplyNoOptions(t *testing.T) {
+	kept, excluded, err := Apply(entries, Options{})
+
+	assert.NoError(t, err)
+	assert.Equal(t, entries, kept)
+	assert.Equal(t, 0, excluded)
+}
+
+func TestApplyExcludeAndStripPrefix(t *testing.T) {
+	kept, excluded, err := Apply(entries, Options{
+		Excludes:      []string{"**/test/**", "*.dll"},
+		StripPrefixes: []string{"/var/lib/jenkins/workspace/job-123/"},
+	})
+
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"lib/foo.jar", "app.war!/WEB-INF/lib/baz.jar"}, locations(kept))
+	assert.Equal(t, 2, excluded)
+}
+
+func TestApplyRewrites(t *testing.T) {
+	kept, _, err := Apply(entries[:2], Options{
+		Rewrites: []string{`^/var/lib/jenkins/workspace/job-\d+/=/build/`, `\.jar$=.JAR`},
+	})
+
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"/build/lib/foo.JAR", "/build/test/fixtures/bar.JAR"}, locations(kept))
+}
+
+func TestApplyRedactBasename(t *testing.T) {
+	kept, _, err := Apply(entries, Options{Redact: RedactBasename})
+
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"foo.jar", "bar.jar", "baz.jar", "qux.dll"}, locations(kept))
+}
+
+func TestApplyRedactHash(t *testing.T) {
+	kept, _, err := Apply(entries[:1], Options{
+		StripPrefixes: []string{"/var/lib/jenkins/workspace/job-123/"},
+		Redact:        RedactHash,
+	})
+
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"c13ff4d4c9907408"}, locations(kept))
+}
+
+func TestRedact(t *testing.T) {
+	assert.Equal(t, entries, Redact(entries, ""))
+	assert.Equal(t, []string{"foo.jar", "bar.jar", "baz.jar", "qux.dll"}, locations(Redact(entries, RedactBasename)))
+}
+
+func TestValidate(t *testing.T) {
+	assert.NoError(t, Options{Rewrites: []string{"a=b=c"}}.Validate())
+	assert.EqualError(t, Options{Rewrites: []string{"nothing"}}.Validate(), `Unable to parse path rewrite "nothing", expected regex=replacement`)
+	assert.Error(t, Options{Rewrites: []string{"(=x"}}.Validate())
+	assert.EqualError(t, Options{Redact: "md5"}.Validate(), `Unknown path redaction "md5", supported redactions are: basename, hash`)
+}
+
+func TestGlob(t *testing.T) {
+	re, err := Glob("/opt/**/*.jar")
+	assert.NoError(t, err)
+	assert.True(t, re.MatchString("/opt/legacy/lib/foo.jar"))
+	assert.False(t, re.MatchString("/usr/lib/foo.jar"))
+
+	re, err = Glob("libssl.so.*")
+	assert.NoError(t, err)
+	assert.True(t, re.MatchString("/usr/lib/libssl.so.1.1"))
+	assert.False(t, re.MatchString("/usr/lib/libssl.so.1.1/other"))
+}
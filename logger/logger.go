@@ -19,80 +19,172 @@ package logger
 
 import (
 	"fmt"
+	"io/ioutil"
 	"os"
-	"path"
+	"path/filepath"
+	"sync"
 
 	"github.com/sirupsen/logrus"
-	"github.com/sonatype-nexus-community/nancy/types"
+	"github.com/sonatype-nexus-community/hashbrowns/types"
 )
 
 const defaultLogFilename = "hashbrowns.combined.log"
 
-var DefaultLogFile = defaultLogFilename
+// Stderr is the log file that means logs are written to stderr, so CI logs capture them inline
+const Stderr = "-"
 
-var logLady *logrus.Logger
+// The formats logs can be written in
+const (
+	FormatJSON = "json"
+	FormatText = "text"
+)
+
+// Options configures where and how logs are written
+type Options struct {
+	// File is the path of the log file, Stderr, or empty for hashbrowns.combined.log in the hashbrowns directory
+	File string
+	// Format is FormatJSON or FormatText, FormatJSON if empty
+	Format string
+	// Append adds to the log file, instead of truncating it
+	Append bool
+	// Level is how verbose logs are, from 0 (errors only) to 4 (trace)
+	Level int
+	// Secrets are values, like tokens, that are replaced with Redacted wherever they would be logged
+	Secrets []string
+	// TracePayloads logs SBOMs and response bodies in full, instead of just their size
+	TracePayloads bool
+}
+
+// logLady is the one logger every package shares. Setup changes where and how it writes instead of replacing it, so
+// packages can hold on to it from when they are loaded, and audits running at the same time never swap it out.
+var logLady = newLogger()
+
+// mu guards the rest of the logger's state, which Setup changes
+var mu sync.Mutex
 
-// GetLogger will either return the existing logger, or setup a new logger
-func GetLogger(loggerFilename string, level int) *logrus.Logger {
-	if logLady == nil {
-		logLevel := getLoggerLevelFromConfig(level)
-		err := setupLogger(loggerFilename, &logLevel)
-		if err != nil {
+var setUp bool
+
+var logFile *os.File
+
+var logLocation string
+
+var tracePayloads bool
+
+// newLogger returns a logger that discards everything, until it is set up
+func newLogger() *logrus.Logger {
+	logger := logrus.New()
+	logger.SetOutput(ioutil.Discard)
+	return logger
+}
+
+// GetLogger returns the logger, setting it up with the default options if it hasn't been set up yet
+func GetLogger(level int) *logrus.Logger {
+	mu.Lock()
+	defer mu.Unlock()
+
+	if !setUp {
+		if err := setup(Options{Level: level}); err != nil {
 			panic(err)
 		}
 	}
 	return logLady
 }
 
+// Logger returns the logger without setting it up, for packages to keep in a variable when they are loaded. It
+// discards everything until Setup or GetLogger is called.
+func Logger() *logrus.Logger {
+	return logLady
+}
+
+// PrintErrorAndLogLocation lets the user know about err, and where to look for more information
 func PrintErrorAndLogLocation(err error) {
-	fmt.Println("Uh oh, an error occurred")
-	fmt.Printf("Error: %v\n", err)
-	location, _ := LogFileLocation()
-	fmt.Printf("Check log file at %s for more information\n", location)
+	fmt.Fprintln(os.Stderr, "Uh oh, an error occurred")
+	fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+	if location := LogFileLocation(); location != "" {
+		fmt.Fprintf(os.Stderr, "Check log file at %s for more information\n", location)
+	}
+}
+
+// LogFileLocation will return the location on disk of the log file, or an empty string if logs go to stderr
+func LogFileLocation() string {
+	mu.Lock()
+	defer mu.Unlock()
+
+	return logLocation
 }
 
-// LogFileLocation will return the location on disk of the log file
-func LogFileLocation() (result string, err error) {
-	result, _ = os.UserHomeDir()
-	err = os.MkdirAll(path.Join(result, types.OssIndexDirName), os.ModePerm)
+// DefaultLogFileLocation returns where logs are written if no log file is given, creating its directory if need be
+func DefaultLogFileLocation() (result string, err error) {
+	home, err := os.UserHomeDir()
 	if err != nil {
 		return
 	}
-	result = path.Join(result, types.OssIndexDirName, DefaultLogFile)
-	return
+	dir := filepath.Join(home, types.HashbrownsDirName)
+	if err = os.MkdirAll(dir, os.ModePerm); err != nil {
+		return
+	}
+	return filepath.Join(dir, defaultLogFilename), nil
 }
 
-func setupLogger(loggerFilename string, level *logrus.Level) (err error) {
-	logLady = logrus.New()
+// Setup changes where and how the logger writes to what opts says
+func Setup(opts Options) (err error) {
+	mu.Lock()
+	defer mu.Unlock()
 
-	if loggerFilename != "" {
-		DefaultLogFile = loggerFilename
-	} else {
-		DefaultLogFile = defaultLogFilename
+	return setup(opts)
+}
+
+func setup(opts Options) (err error) {
+	var formatter logrus.Formatter
+	switch opts.Format {
+	case FormatJSON, "":
+		formatter = &logrus.JSONFormatter{DisableHTMLEscape: true}
+	case FormatText:
+		formatter = &logrus.TextFormatter{FullTimestamp: true, DisableColors: opts.File != Stderr}
+	default:
+		return fmt.Errorf("Unknown log format %q, supported formats are: %s, %s", opts.Format, FormatJSON, FormatText)
 	}
 
-	if level == nil {
-		logLady.Level = logrus.ErrorLevel
-	} else {
-		// Done because report caller adds 20-40% overhead per logrus docs, only set this when we want to debug
-		if *level > logrus.DebugLevel {
-			logLady.SetReportCaller(true)
+	var file *os.File
+	location := opts.File
+	switch location {
+	case Stderr:
+		location = ""
+	case "":
+		if location, err = DefaultLogFileLocation(); err != nil {
+			return
+		}
+		fallthrough
+	default:
+		flags := os.O_CREATE | os.O_WRONLY | os.O_TRUNC
+		if opts.Append {
+			flags = os.O_CREATE | os.O_WRONLY | os.O_APPEND
+		}
+		if file, err = os.OpenFile(location, flags, 0666); err != nil {
+			return
 		}
-		logLady.Level = *level
 	}
 
-	logLady.Formatter = &logrus.JSONFormatter{DisableHTMLEscape: true}
-
-	location, err := LogFileLocation()
-	if err != nil {
-		return
+	level := getLoggerLevelFromConfig(opts.Level)
+	if file != nil {
+		logLady.SetOutput(file)
+	} else {
+		logLady.SetOutput(os.Stderr)
 	}
-
-	file, err := os.OpenFile(location, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0666)
-	if err != nil {
-		return
+	logLady.SetFormatter(formatter)
+	logLady.SetLevel(level)
+	// Done because report caller adds 20-40% overhead per logrus docs, only set this when we want to debug
+	logLady.SetReportCaller(level > logrus.DebugLevel)
+	hooks := logrus.LevelHooks{}
+	hooks.Add(newRedactHook(opts.Secrets))
+	logLady.ReplaceHooks(hooks)
+
+	if logFile != nil {
+		_ = logFile.Close()
 	}
-	logLady.Out = file
+	logFile, logLocation = file, location
+	tracePayloads = opts.TracePayloads
+	setUp = true
 
 	return
 }
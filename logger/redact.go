@@ -0,0 +1,112 @@
+//
+// Copyright © 2020-present Sonatype Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+package logger
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+)
+
+// Redacted replaces credentials in the logs
+const Redacted = "[REDACTED]"
+
+// secretField matches the names of fields whose values are always redacted
+var secretField = regexp.MustCompile(`(?i)token|password|secret|authorization`)
+
+// authorizationHeader matches the credentials of an Authorization header, however it was printed
+var authorizationHeader = regexp.MustCompile(`(?i)(authorization\W{1,4}(?:basic |bearer |token )?)[^\s"',\]}]+`)
+
+// urlPassword matches the password in the user info of a URL
+var urlPassword = regexp.MustCompile(`(://[^/:@\s]+:)[^/@\s]+@`)
+
+// redactHook removes credentials from log entries before they are written
+type redactHook struct {
+	secrets []string
+}
+
+func newRedactHook(secrets []string) *redactHook {
+	h := &redactHook{}
+	for _, s := range secrets {
+		if s != "" {
+			h.secrets = append(h.secrets, s)
+		}
+	}
+	return h
+}
+
+func (h *redactHook) Levels() []logrus.Level {
+	return logrus.AllLevels
+}
+
+func (h *redactHook) Fire(entry *logrus.Entry) error {
+	entry.Message = h.redact(entry.Message)
+
+	data := make(logrus.Fields, len(entry.Data))
+	for k, v := range entry.Data {
+		data[k] = h.redactValue(k, v)
+	}
+	entry.Data = data
+
+	return nil
+}
+
+func (h *redactHook) redactValue(key string, value interface{}) interface{} {
+	if secretField.MatchString(key) {
+		return Redacted
+	}
+
+	switch v := value.(type) {
+	case nil, bool, int, int64, float64:
+		return v
+	case string:
+		return h.redact(v)
+	case error:
+		if r := h.redact(v.Error()); r != v.Error() {
+			return r
+		}
+		return v
+	default:
+		s := fmt.Sprintf("%+v", v)
+		if r := h.redact(s); r != s {
+			return r
+		}
+		return v
+	}
+}
+
+func (h *redactHook) redact(s string) string {
+	for _, secret := range h.secrets {
+		s = strings.Replace(s, secret, Redacted, -1)
+	}
+	s = authorizationHeader.ReplaceAllString(s, "${1}"+Redacted)
+	return urlPassword.ReplaceAllString(s, "${1}"+Redacted+"@")
+}
+
+// Payload describes a payload, like an SBOM or a response body, as fields to log. Payloads can be large and full of
+// internal paths, so only their size is logged, unless Options.TracePayloads was set
+func Payload(name string, payload string) logrus.Fields {
+	mu.Lock()
+	trace := tracePayloads
+	mu.Unlock()
+
+	if trace {
+		return logrus.Fields{name: payload}
+	}
+	return logrus.Fields{name + "_size": len(payload)}
+}
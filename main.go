@@ -16,28 +16,19 @@
 package main
 
 import (
-	"fmt"
+	"errors"
 	"os"
 
-	"github.com/common-nighthawk/go-figure"
-	"github.com/sonatype-nexus-community/hashbrowns/buildversion"
 	"github.com/sonatype-nexus-community/hashbrowns/cmd"
 )
 
 func main() {
-	printHeader(true)
-
 	if err := cmd.Execute(); err != nil {
+		var exitErr cmd.ExitError
+		if errors.As(err, &exitErr) {
+			os.Exit(exitErr.Code)
+		}
 		os.Exit(1)
 	}
 	os.Exit(0)
 }
-
-func printHeader(print bool) {
-	if print {
-		figure.NewFigure("Hashbrowns", "isometric1", true).Print()
-		figure.NewFigure("By Sonatype & Friends", "pepper", true).Print()
-
-		fmt.Println("Hashbrowns version: " + buildversion.BuildVersion)
-	}
-}
@@ -0,0 +1,107 @@
+//
+// Copyright © 2020-present Sonatype Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+package parse
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/sonatype-nexus-community/go-sona-types/cyclonedx"
+)
+
+const (
+	// FormatShasum is the output of shasum/sha1sum, and the default input format
+	FormatShasum = "shasum"
+	// FormatCSV is a comma separated inventory, with columns selected by Options
+	FormatCSV = "csv"
+	// FormatJSONL is one JSON object per line, with sha1 and path (or location) keys
+	FormatJSONL = "jsonl"
+	// FormatCycloneDX is a CycloneDX SBOM in XML or JSON, with SHA-1 component hashes
+	FormatCycloneDX = "cyclonedx"
+	// FormatSPDX is an SPDX document in JSON or tag-value, with SHA1 file checksums
+	FormatSPDX = "spdx"
+)
+
+// Options configures how an input is parsed
+type Options struct {
+	Format string
+	// CSVSha1Column and CSVPathColumn are either header names, or zero based column indexes.
+	// If both are indexes, the CSV is assumed to have no header row.
+	CSVSha1Column string
+	CSVPathColumn string
+	// KeepDuplicates keeps every location a sha1 is found at, for callers such as diffs that care about locations
+	KeepDuplicates bool
+}
+
+// Parser reads an input in a given format, and returns the sha1s and locations found in it
+type Parser func(r io.Reader, opts Options) ([]cyclonedx.Sha1SBOM, error)
+
+var parsers = map[string]Parser{
+	FormatShasum:    parseShasum,
+	FormatCSV:       parseCSV,
+	FormatJSONL:     parseJSONL,
+	FormatCycloneDX: parseCycloneDX,
+	FormatSPDX:      parseSPDX,
+}
+
+// Formats returns the names of all supported input formats
+func Formats() (formats []string) {
+	for k := range parsers {
+		formats = append(formats, k)
+	}
+	sort.Strings(formats)
+	return
+}
+
+// File accepts a path to a file in any supported input format, and returns the sha1s in it
+// as a slice of types.Sha1SBOM, or an error if there was an issue processing the file
+func File(path string, opts Options) (sha1s []cyclonedx.Sha1SBOM, err error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return
+	}
+	defer file.Close()
+
+	return Input(file, opts)
+}
+
+// Input parses r using the parser for opts.Format, and removes any duplicate sha1s unless opts.KeepDuplicates is set
+func Input(r io.Reader, opts Options) (sha1s []cyclonedx.Sha1SBOM, err error) {
+	format := strings.ToLower(opts.Format)
+	if format == "" {
+		format = FormatShasum
+	}
+
+	parser, ok := parsers[format]
+	if !ok {
+		return nil, fmt.Errorf("Unknown input format %q, supported formats are: %s", opts.Format, strings.Join(Formats(), ", "))
+	}
+
+	log.WithField("format", format).Info("Parsing input")
+	sha1s, err = parser(r, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	if !opts.KeepDuplicates {
+		sha1s = RemoveDuplicates(sha1s)
+	}
+
+	return
+}
@@ -0,0 +1,138 @@
+//
+// Copyright © 2020-present Sonatype Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+package parse
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/sonatype-nexus-community/go-sona-types/cyclonedx"
+)
+
+const (
+	defaultCSVSha1Column = "sha1"
+	defaultCSVPathColumn = "path"
+)
+
+func parseCSV(r io.Reader, opts Options) (sha1s []cyclonedx.Sha1SBOM, err error) {
+	sha1Column := opts.CSVSha1Column
+	if sha1Column == "" {
+		sha1Column = defaultCSVSha1Column
+	}
+	pathColumn := opts.CSVPathColumn
+	if pathColumn == "" {
+		pathColumn = defaultCSVPathColumn
+	}
+
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	sha1Index, sha1IsIndex := columnIndex(sha1Column)
+	pathIndex, pathIsIndex := columnIndex(pathColumn)
+
+	if !sha1IsIndex || !pathIsIndex {
+		header, err := reader.Read()
+		if err != nil {
+			return nil, fmt.Errorf("Unable to read CSV header: %v", err)
+		}
+		if !sha1IsIndex {
+			if sha1Index, err = headerIndex(header, sha1Column); err != nil {
+				return nil, err
+			}
+		}
+		if !pathIsIndex {
+			if pathIndex, err = headerIndex(header, pathColumn); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	for row := 1; ; row++ {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		if sha1Index >= len(record) || pathIndex >= len(record) {
+			return nil, fmt.Errorf("CSV record %d does not have enough columns", row)
+		}
+		if record[sha1Index] == "" {
+			continue
+		}
+		sha1s = append(sha1s, cyclonedx.Sha1SBOM{Sha1: record[sha1Index], Location: record[pathIndex]})
+	}
+
+	return
+}
+
+func columnIndex(column string) (int, bool) {
+	i, err := strconv.Atoi(column)
+	if err != nil || i < 0 {
+		return 0, false
+	}
+	return i, true
+}
+
+func headerIndex(header []string, column string) (int, error) {
+	for i, v := range header {
+		if strings.EqualFold(strings.TrimSpace(v), column) {
+			return i, nil
+		}
+	}
+	return 0, fmt.Errorf("CSV header does not have a column named %q", column)
+}
+
+type jsonlEntry struct {
+	Sha1     string `json:"sha1"`
+	Path     string `json:"path"`
+	Location string `json:"location"`
+}
+
+func parseJSONL(r io.Reader, _ Options) (sha1s []cyclonedx.Sha1SBOM, err error) {
+	scanner := bufio.NewScanner(r)
+	line := 0
+	for scanner.Scan() {
+		line++
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" {
+			continue
+		}
+
+		var entry jsonlEntry
+		if err = json.Unmarshal([]byte(text), &entry); err != nil {
+			return nil, fmt.Errorf("Unable to parse JSON on line %d: %v", line, err)
+		}
+		if entry.Sha1 == "" {
+			return nil, fmt.Errorf("JSON on line %d has no sha1", line)
+		}
+
+		location := entry.Path
+		if location == "" {
+			location = entry.Location
+		}
+		sha1s = append(sha1s, cyclonedx.Sha1SBOM{Sha1: entry.Sha1, Location: location})
+	}
+
+	return sha1s, scanner.Err()
+}
@@ -17,60 +17,65 @@ package parse
 
 import (
 	"bufio"
-	"os"
+	"fmt"
+	"io"
 	"strings"
 
-	"github.com/sirupsen/logrus"
 	"github.com/sonatype-nexus-community/go-sona-types/cyclonedx"
 	"github.com/sonatype-nexus-community/hashbrowns/logger"
 )
 
-var log *logrus.Logger
+var log = logger.Logger()
 
 // Sha1File accepts a path to a file that has shasums for files, and returns them as a
 // slice of types.Sha1SBOM, or an error if there was an issue processing the file
 func Sha1File(path string) (sha1s []cyclonedx.Sha1SBOM, err error) {
-	log = logger.GetLogger("", 0)
-
-	file, err := os.Open(path)
-	if err != nil {
-		return
-	}
-	defer file.Close()
+	return File(path, Options{Format: FormatShasum})
+}
 
-	scanner := bufio.NewScanner(file)
+func parseShasum(r io.Reader, _ Options) (sha1s []cyclonedx.Sha1SBOM, err error) {
+	scanner := bufio.NewScanner(r)
 	for scanner.Scan() {
-		sha1s = append(sha1s, parseSpaceSeperatedLocationAndSha1(scanner))
+		if strings.TrimSpace(scanner.Text()) == "" {
+			continue
+		}
+		sha1, err := parseSpaceSeperatedLocationAndSha1(scanner)
+		if err != nil {
+			return nil, err
+		}
+		sha1s = append(sha1s, sha1)
 	}
 
-	sha1s = removeDuplicates(sha1s)
-
-	return
+	return sha1s, scanner.Err()
 }
 
-func parseSpaceSeperatedLocationAndSha1(scanner *bufio.Scanner) (sha1 cyclonedx.Sha1SBOM) {
-	s := strings.Split(scanner.Text(), "  ")
+func parseSpaceSeperatedLocationAndSha1(scanner *bufio.Scanner) (sha1 cyclonedx.Sha1SBOM, err error) {
+	s := strings.SplitN(scanner.Text(), "  ", 2)
+	if len(s) != 2 {
+		return sha1, fmt.Errorf("Unable to parse shasum line, expected sha1 and location separated by two spaces: %q", scanner.Text())
+	}
 	sha1.Sha1 = s[0]
 	sha1.Location = s[1]
 
 	return
 }
 
-func removeDuplicates(sha1s []cyclonedx.Sha1SBOM) (dedupedSha1s []cyclonedx.Sha1SBOM) {
-	log.WithField("sha1s", sha1s).Debug("Beginning to remove duplicates")
+// RemoveDuplicates returns sha1s with any repeated sha1 removed, keeping the first location it was found at
+func RemoveDuplicates(sha1s []cyclonedx.Sha1SBOM) (dedupedSha1s []cyclonedx.Sha1SBOM) {
+	log.WithField("entries", len(sha1s)).Debug("Beginning to remove duplicates")
 	encountered := map[string]bool{}
 
 	for _, v := range sha1s {
 		if encountered[v.Sha1] {
-			log.WithField("sha1", v).Trace("Found duplicate sha1, eliminating it")
+			log.WithField("sha1", v.Sha1).Trace("Found duplicate sha1, eliminating it")
 		} else {
-			log.WithField("sha1", v).Trace("Unique sha1, adding it")
+			log.WithField("sha1", v.Sha1).Trace("Unique sha1, adding it")
 			encountered[v.Sha1] = true
 			dedupedSha1s = append(dedupedSha1s, v)
 		}
 	}
 
-	log.WithField("sha1s", dedupedSha1s).Debug("Finished removeing duplicates")
+	log.WithField("entries", len(dedupedSha1s)).Debug("Finished removing duplicates")
 
 	return
 }
@@ -21,6 +21,7 @@ import (
 	"strings"
 	"testing"
 
+	"github.com/sonatype-nexus-community/go-sona-types/cyclonedx"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -46,3 +47,96 @@ func TestParseSha1FileBadPath(t *testing.T) {
 	// older versions would yield *os.PathError
 	assert.True(t, strings.HasSuffix(typeOfError, "s.PathError"))
 }
+
+func TestParseKeepDuplicates(t *testing.T) {
+	input := "9987ca4f73d5ea0e534dfbf19238552df4de507e  a/foo.jar\n9987ca4f73d5ea0e534dfbf19238552df4de507e  b/foo.jar\n"
+
+	results, err := Input(strings.NewReader(input), Options{})
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(results))
+
+	results, err = Input(strings.NewReader(input), Options{KeepDuplicates: true})
+	assert.Nil(t, err)
+	assert.Equal(t, 2, len(results))
+	assert.Equal(t, "b/foo.jar", results[1].Location)
+}
+
+func assertFooAndBar(t *testing.T, results []cyclonedx.Sha1SBOM, fooLocation, barLocation string) {
+	assert.Equal(t, 2, len(results))
+	assert.Equal(t, fooLocation, results[0].Location)
+	assert.Equal(t, "9987ca4f73d5ea0e534dfbf19238552df4de507e", results[0].Sha1)
+	assert.Equal(t, barLocation, results[1].Location)
+	assert.Equal(t, "2a72a07fbc9de22308d12a32f7d33504349e63c9", results[1].Sha1)
+}
+
+func TestParseCSVFile(t *testing.T) {
+	results, err := File(path.Join("testdata", "inventory.csv"), Options{Format: FormatCSV})
+
+	assert.Nil(t, err)
+	assertFooAndBar(t, results, "/opt/app/lib/foo.jar", "/opt/app/lib/bar.jar")
+}
+
+func TestParseCSVColumnIndexes(t *testing.T) {
+	input := "/opt/app/lib/foo.jar,9987ca4f73d5ea0e534dfbf19238552df4de507e\n/opt/app/lib/bar.jar,2a72a07fbc9de22308d12a32f7d33504349e63c9\n"
+	results, err := Input(strings.NewReader(input), Options{Format: FormatCSV, CSVSha1Column: "1", CSVPathColumn: "0"})
+
+	assert.Nil(t, err)
+	assertFooAndBar(t, results, "/opt/app/lib/foo.jar", "/opt/app/lib/bar.jar")
+}
+
+func TestParseCSVMissingColumn(t *testing.T) {
+	_, err := File(path.Join("testdata", "inventory.csv"), Options{Format: FormatCSV, CSVSha1Column: "checksum"})
+
+	assert.NotNil(t, err)
+	assert.Equal(t, "CSV header does not have a column named \"checksum\"", err.Error())
+}
+
+func TestParseJSONLFile(t *testing.T) {
+	results, err := File(path.Join("testdata", "inventory.jsonl"), Options{Format: FormatJSONL})
+
+	assert.Nil(t, err)
+	assertFooAndBar(t, results, "/opt/app/lib/foo.jar", "/opt/app/lib/bar.jar")
+}
+
+func TestParseCycloneDXXMLFile(t *testing.T) {
+	results, err := File(path.Join("testdata", "bom.xml"), Options{Format: FormatCycloneDX})
+
+	assert.Nil(t, err)
+	assertFooAndBar(t, results, "foo.jar", "com.example/bar.jar")
+}
+
+func TestParseCycloneDXJSONFile(t *testing.T) {
+	results, err := File(path.Join("testdata", "bom.json"), Options{Format: FormatCycloneDX})
+
+	assert.Nil(t, err)
+	assertFooAndBar(t, results, "foo.jar", "com.example/bar.jar")
+}
+
+func TestParseSPDXJSONFile(t *testing.T) {
+	results, err := File(path.Join("testdata", "spdx.json"), Options{Format: FormatSPDX})
+
+	assert.Nil(t, err)
+	assertFooAndBar(t, results, "./lib/foo.jar", "./lib/bar.jar")
+}
+
+func TestParseSPDXTagValueFile(t *testing.T) {
+	results, err := File(path.Join("testdata", "spdx.spdx"), Options{Format: FormatSPDX})
+
+	assert.Nil(t, err)
+	assertFooAndBar(t, results, "./lib/foo.jar", "./lib/bar.jar")
+}
+
+func TestParseUnknownFormat(t *testing.T) {
+	results, err := File(path.Join("testdata", "thing.txt"), Options{Format: "xlsx"})
+
+	assert.Nil(t, results)
+	assert.NotNil(t, err)
+	assert.Equal(t, "Unknown input format \"xlsx\", supported formats are: csv, cyclonedx, jsonl, shasum, spdx", err.Error())
+}
+
+func TestParseShasumMalformedLine(t *testing.T) {
+	results, err := Input(strings.NewReader("9987ca4f73d5ea0e534dfbf19238552df4de507e main.go\n"), Options{})
+
+	assert.Nil(t, results)
+	assert.NotNil(t, err)
+}
@@ -0,0 +1,173 @@
+//
+// Copyright © 2020-present Sonatype Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+package parse
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"strings"
+
+	"github.com/sonatype-nexus-community/go-sona-types/cyclonedx"
+)
+
+// CycloneDX types, only the parts needed to find SHA-1 hashes. XML tags are left without a
+// namespace so any CycloneDX schema version will unmarshal.
+type cdxBom struct {
+	Components []cdxComponent `xml:"components>component" json:"components"`
+}
+
+type cdxComponent struct {
+	Name       string         `xml:"name" json:"name"`
+	Group      string         `xml:"group" json:"group"`
+	Hashes     []cdxHash      `xml:"hashes>hash" json:"hashes"`
+	Components []cdxComponent `xml:"components>component" json:"components"`
+}
+
+type cdxHash struct {
+	Alg     string `xml:"alg,attr" json:"alg"`
+	Content string `xml:",chardata" json:"content"`
+}
+
+// SPDX types, only the parts needed to find SHA1 checksums
+type spdxDocument struct {
+	Files    []spdxFile    `json:"files"`
+	Packages []spdxPackage `json:"packages"`
+}
+
+type spdxFile struct {
+	FileName  string         `json:"fileName"`
+	Checksums []spdxChecksum `json:"checksums"`
+}
+
+type spdxPackage struct {
+	Name            string         `json:"name"`
+	PackageFileName string         `json:"packageFileName"`
+	Checksums       []spdxChecksum `json:"checksums"`
+}
+
+type spdxChecksum struct {
+	Algorithm     string `json:"algorithm"`
+	ChecksumValue string `json:"checksumValue"`
+}
+
+func parseCycloneDX(r io.Reader, _ Options) (sha1s []cyclonedx.Sha1SBOM, err error) {
+	content, err := ioutil.ReadAll(r)
+	if err != nil {
+		return
+	}
+	content = bytes.TrimSpace(content)
+
+	var bom cdxBom
+	if bytes.HasPrefix(content, []byte("<")) {
+		err = xml.Unmarshal(content, &bom)
+	} else {
+		err = json.Unmarshal(content, &bom)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("Unable to parse CycloneDX SBOM: %v", err)
+	}
+
+	return cycloneDXComponentSha1s(bom.Components), nil
+}
+
+func cycloneDXComponentSha1s(components []cdxComponent) (sha1s []cyclonedx.Sha1SBOM) {
+	for _, c := range components {
+		location := c.Name
+		if c.Group != "" {
+			location = c.Group + "/" + c.Name
+		}
+		for _, h := range c.Hashes {
+			if isSha1Algorithm(h.Alg) {
+				sha1s = append(sha1s, cyclonedx.Sha1SBOM{Sha1: strings.TrimSpace(h.Content), Location: location})
+			}
+		}
+		sha1s = append(sha1s, cycloneDXComponentSha1s(c.Components)...)
+	}
+	return
+}
+
+func parseSPDX(r io.Reader, _ Options) (sha1s []cyclonedx.Sha1SBOM, err error) {
+	content, err := ioutil.ReadAll(r)
+	if err != nil {
+		return
+	}
+	content = bytes.TrimSpace(content)
+
+	if !bytes.HasPrefix(content, []byte("{")) {
+		return parseSPDXTagValue(bytes.NewReader(content))
+	}
+
+	var doc spdxDocument
+	if err = json.Unmarshal(content, &doc); err != nil {
+		return nil, fmt.Errorf("Unable to parse SPDX document: %v", err)
+	}
+
+	for _, f := range doc.Files {
+		sha1s = append(sha1s, spdxChecksumSha1s(f.FileName, f.Checksums)...)
+	}
+	for _, p := range doc.Packages {
+		location := p.PackageFileName
+		if location == "" {
+			location = p.Name
+		}
+		sha1s = append(sha1s, spdxChecksumSha1s(location, p.Checksums)...)
+	}
+
+	return
+}
+
+func spdxChecksumSha1s(location string, checksums []spdxChecksum) (sha1s []cyclonedx.Sha1SBOM) {
+	for _, c := range checksums {
+		if isSha1Algorithm(c.Algorithm) {
+			sha1s = append(sha1s, cyclonedx.Sha1SBOM{Sha1: c.ChecksumValue, Location: location})
+		}
+	}
+	return
+}
+
+func parseSPDXTagValue(r io.Reader) (sha1s []cyclonedx.Sha1SBOM, err error) {
+	var location string
+
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		s := strings.SplitN(scanner.Text(), ":", 2)
+		if len(s) != 2 {
+			continue
+		}
+		tag, value := strings.TrimSpace(s[0]), strings.TrimSpace(s[1])
+
+		switch tag {
+		case "FileName", "PackageName", "PackageFileName":
+			location = value
+		case "FileChecksum", "PackageChecksum":
+			checksum := strings.SplitN(value, ":", 2)
+			if len(checksum) == 2 && isSha1Algorithm(checksum[0]) {
+				sha1s = append(sha1s, cyclonedx.Sha1SBOM{Sha1: strings.TrimSpace(checksum[1]), Location: location})
+			}
+		}
+	}
+
+	return sha1s, scanner.Err()
+}
+
+func isSha1Algorithm(alg string) bool {
+	return strings.EqualFold(strings.Replace(strings.TrimSpace(alg), "-", "", -1), "sha1")
+}
@@ -0,0 +1,177 @@
+//
+// Copyright © 2020-present Sonatype Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+// Package progress shows how long running steps are getting on. At a terminal a line is redrawn in place with a
+// spinner, otherwise a line is printed every so often, so logs of slow runs in CI still show what is happening.
+package progress
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"sync"
+	"time"
+)
+
+var spinner = []string{"|", "/", "-", `\`}
+
+var (
+	// redrawInterval is how often the line is redrawn at a terminal
+	redrawInterval = 100 * time.Millisecond
+	// logInterval is how often a line is printed for a step that is still running, when not at a terminal
+	logInterval = 10 * time.Second
+)
+
+var (
+	mu       sync.Mutex
+	out      io.Writer = ioutil.Discard
+	terminal bool
+)
+
+// Setup sets where progress is shown, and whether it is a terminal that lines can be redrawn on. Nothing is shown
+// until Setup is called, or if w is ioutil.Discard.
+func Setup(w io.Writer, isTerminal bool) {
+	mu.Lock()
+	defer mu.Unlock()
+
+	out = w
+	terminal = isTerminal
+}
+
+// Task is a single long running step. Steps that finish quickly are never shown.
+type Task struct {
+	name     string
+	start    time.Time
+	status   string
+	out      io.Writer
+	terminal bool
+	frame    int
+	shown    bool
+	stop     chan struct{}
+	stopped  chan struct{}
+}
+
+// Start begins showing the progress of a step called name, until Done is called
+func Start(name string) *Task {
+	mu.Lock()
+	t := &Task{
+		name:     name,
+		start:    time.Now(),
+		out:      out,
+		terminal: terminal,
+		stop:     make(chan struct{}),
+		stopped:  make(chan struct{}),
+	}
+	mu.Unlock()
+
+	if t.out == ioutil.Discard {
+		close(t.stopped)
+		return t
+	}
+
+	interval := logInterval
+	if t.terminal {
+		interval = redrawInterval
+	}
+	go t.run(interval)
+
+	return t
+}
+
+func (t *Task) run(interval time.Duration) {
+	defer close(t.stopped)
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-t.stop:
+			return
+		case <-ticker.C:
+			t.draw()
+		}
+	}
+}
+
+func (t *Task) draw() {
+	mu.Lock()
+	defer mu.Unlock()
+
+	if t.terminal {
+		t.frame++
+		fmt.Fprintf(t.out, "\r\033[K%s %s", spinner[t.frame%len(spinner)], t.line())
+	} else {
+		fmt.Fprintln(t.out, t.line())
+	}
+	t.shown = true
+}
+
+func (t *Task) line() string {
+	elapsed := time.Since(t.start).Round(100 * time.Millisecond)
+	if t.status == "" {
+		return fmt.Sprintf("%s (%s)", t.name, elapsed)
+	}
+	return fmt.Sprintf("%s: %s (%s)", t.name, t.status, elapsed)
+}
+
+// Update sets what the step is doing now, which is shown the next time the progress is drawn
+func (t *Task) Update(format string, args ...interface{}) {
+	if t == nil {
+		return
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+
+	t.status = fmt.Sprintf(format, args...)
+}
+
+// Done finishes the step, leaving a line with how it went if its progress was shown at all
+func (t *Task) Done(format string, args ...interface{}) {
+	if t == nil {
+		return
+	}
+
+	close(t.stop)
+	<-t.stopped
+
+	mu.Lock()
+	defer mu.Unlock()
+
+	if !t.shown {
+		return
+	}
+	t.status = fmt.Sprintf(format, args...)
+	if t.terminal {
+		fmt.Fprintf(t.out, "\r\033[K%s\n", t.line())
+	} else {
+		fmt.Fprintln(t.out, t.line())
+	}
+}
+
+// Bytes formats n bytes for people, like 45.2 MB
+func Bytes(n int64) string {
+	const unit = 1000
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	div, exp := int64(unit), 0
+	for v := n / unit; v >= unit; v /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %cB", float64(n)/float64(div), "kMGTPE"[exp])
+}
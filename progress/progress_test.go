@@ -0,0 +1,101 @@
+//
+// Copyright © 2020-present Sonatype Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+package progress
+
+import (
+	"bytes"
+	"io/ioutil"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// syncBuffer is a buffer that tasks can draw to from their own goroutines
+type syncBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func setup(t *testing.T, isTerminal bool) *syncBuffer {
+	origRedrawInterval, origLogInterval := redrawInterval, logInterval
+	t.Cleanup(func() {
+		redrawInterval, logInterval = origRedrawInterval, origLogInterval
+		Setup(ioutil.Discard, false)
+	})
+	redrawInterval, logInterval = 5*time.Millisecond, 5*time.Millisecond
+
+	b := &syncBuffer{}
+	Setup(b, isTerminal)
+	return b
+}
+
+func TestTaskLogLines(t *testing.T) {
+	b := setup(t, false)
+
+	task := Start("Hashing")
+	task.Update("3 files")
+	time.Sleep(30 * time.Millisecond)
+	task.Done("5 files")
+
+	lines := strings.Split(strings.TrimSpace(b.buf.String()), "\n")
+	assert.True(t, len(lines) > 1)
+	assert.True(t, strings.HasPrefix(lines[0], "Hashing: 3 files ("))
+	assert.True(t, strings.HasPrefix(lines[len(lines)-1], "Hashing: 5 files ("))
+}
+
+func TestTaskTerminal(t *testing.T) {
+	b := setup(t, true)
+
+	task := Start("Submitting SBOM")
+	time.Sleep(30 * time.Millisecond)
+	task.Done("sent")
+
+	assert.True(t, strings.HasPrefix(b.buf.String(), "\r\033[K| Submitting SBOM (") || strings.HasPrefix(b.buf.String(), "\r\033[K/ Submitting SBOM ("))
+	assert.Contains(t, b.buf.String(), "\r\033[KSubmitting SBOM: sent (")
+	assert.True(t, strings.HasSuffix(b.buf.String(), ")\n"))
+}
+
+func TestQuickTaskNotShown(t *testing.T) {
+	b := setup(t, false)
+	logInterval = time.Hour
+
+	task := Start("Building SBOM")
+	task.Done("3 entries")
+
+	assert.Equal(t, "", b.buf.String())
+}
+
+func TestNilTask(t *testing.T) {
+	var task *Task
+	task.Update("nothing")
+	task.Done("nothing")
+}
+
+func TestBytes(t *testing.T) {
+	assert.Equal(t, "999 B", Bytes(999))
+	assert.Equal(t, "1.5 kB", Bytes(1500))
+	assert.Equal(t, "45.2 MB", Bytes(45200000))
+	assert.Equal(t, "2.0 GB", Bytes(2000000000))
+}
@@ -0,0 +1,183 @@
+//
+// Copyright © 2020-present Sonatype Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+package report
+
+import (
+	"html/template"
+	"io"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/sonatype-nexus-community/hashbrowns/buildversion"
+)
+
+// The status of each audited entry in an HTML report
+const (
+	statusViolation = "Violation"
+	statusWaived    = "Waived"
+	statusOK        = "OK"
+)
+
+type htmlPage struct {
+	Version   string
+	Generated string
+	Reports   []htmlReport
+}
+
+type htmlReport struct {
+	Report
+	Components []htmlComponent
+	Waivers    []htmlComponent
+	Locations  []htmlLocation
+}
+
+type htmlComponent struct {
+	Sha1       string
+	Location   string
+	Severity   string
+	Violations []Violation
+}
+
+type htmlLocation struct {
+	Sha1     string
+	Location string
+	Status   string
+}
+
+var htmlTemplate = template.Must(template.New("report").Funcs(template.FuncMap{
+	"lower": strings.ToLower,
+}).Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>Hashbrowns audit report</title>
+<style>
+body { font-family: -apple-system, "Segoe UI", Helvetica, Arial, sans-serif; margin: 2em; color: #222; }
+h1 { font-size: 1.6em; }
+h2 { font-size: 1.3em; margin-top: 2em; border-bottom: 1px solid #ccc; }
+h3 { font-size: 1.1em; }
+table { border-collapse: collapse; margin: 0.5em 0 1.5em; }
+th, td { border: 1px solid #ddd; padding: 0.3em 0.6em; text-align: left; vertical-align: top; }
+th { background: #f4f4f4; }
+code { font-size: 0.9em; }
+.badge { display: inline-block; padding: 0.1em 0.6em; border-radius: 0.3em; color: #fff; font-weight: bold; }
+.pass, .ok { background: #2e7d32; }
+.skipped, .waived { background: #757575; }
+.failure, .violation, .critical, .high { background: #c62828; }
+.medium { background: #ef6c00; }
+.low { background: #f9a825; }
+.error { background: #6a1b9a; }
+footer { margin-top: 3em; color: #777; font-size: 0.9em; }
+</style>
+</head>
+<body>
+<h1>Hashbrowns audit report</h1>
+{{range .Reports}}
+<h2>{{.Application}}{{if .Stage}} ({{.Stage}}){{end}}</h2>
+<table>
+<tr><th>Result</th><td><span class="badge {{.Outcome}}">{{.Summary}}</span></td></tr>
+{{if .Path}}<tr><th>Path</th><td><code>{{.Path}}</code></td></tr>{{end}}
+{{if .Backend}}<tr><th>Backend</th><td>{{.Backend}}</td></tr>{{end}}
+<tr><th>Entries audited</th><td>{{.Entries}}</td></tr>
+<tr><th>Components violating policy</th><td>{{len .Components}}</td></tr>
+<tr><th>Components waived</th><td>{{len .Waivers}}</td></tr>
+{{if .ReportURL}}<tr><th>Full report</th><td><a href="{{.ReportURL}}">{{.ReportURL}}</a></td></tr>{{end}}
+</table>
+{{if .Components}}
+<h3>Policy violations</h3>
+{{with .ViolationsNote}}<p>{{.}}</p>
+{{end}}<table>
+<tr><th>Location</th><th>Sha1</th><th>Severity</th><th>Policies</th></tr>
+{{range .Components}}<tr><td><code>{{.Location}}</code></td><td><code>{{.Sha1}}</code></td><td><span class="badge {{lower .Severity}}">{{.Severity}}</span></td><td>{{range .Violations}}{{.Reason}}{{if .ThreatLevel}} (threat level {{.ThreatLevel}}){{end}}<br>{{end}}</td></tr>
+{{end}}</table>
+{{end}}
+{{if .Waivers}}
+<h3>Waived violations</h3>
+<table>
+<tr><th>Location</th><th>Sha1</th><th>Severity</th><th>Policies</th><th>Justification</th></tr>
+{{range .Waivers}}<tr><td><code>{{.Location}}</code></td><td><code>{{.Sha1}}</code></td><td>{{.Severity}}</td><td>{{range .Violations}}{{.Reason}}<br>{{end}}</td><td>{{(index .Violations 0).Waiver}}</td></tr>
+{{end}}</table>
+{{end}}
+{{if .Locations}}
+<h3>Audited files</h3>
+<table>
+<tr><th>Location</th><th>Sha1</th><th>Status</th></tr>
+{{range .Locations}}<tr><td><code>{{.Location}}</code></td><td><code>{{.Sha1}}</code></td><td><span class="badge {{lower .Status}}">{{.Status}}</span></td></tr>
+{{end}}</table>
+{{end}}
+{{end}}
+<footer>Generated by hashbrowns {{.Version}} on {{.Generated}}</footer>
+</body>
+</html>
+`))
+
+// WriteHTML writes reports as a self contained HTML page, with a summary, the violations of each component, and the
+// status of every audited file for each report. generated is when the page says it was made.
+func WriteHTML(w io.Writer, reports []Report, generated time.Time) error {
+	page := htmlPage{
+		Version:   buildversion.BuildVersion,
+		Generated: generated.Format(time.RFC1123),
+	}
+	for _, r := range reports {
+		page.Reports = append(page.Reports, htmlReport{
+			Report:     r,
+			Components: htmlComponents(r.Violations),
+			Waivers:    htmlComponents(r.Waived),
+			Locations:  htmlLocations(r),
+		})
+	}
+	return htmlTemplate.Execute(w, page)
+}
+
+func htmlComponents(violations []Violation) (components []htmlComponent) {
+	for _, group := range groupViolations(violations) {
+		components = append(components, htmlComponent{
+			Sha1:       group[0].Sha1,
+			Location:   group[0].Location,
+			Severity:   group[0].Severity,
+			Violations: group,
+		})
+	}
+	return
+}
+
+// htmlLocations has the status of every audited entry, sorted by location. An entry matches a violation at the same
+// location with a matching sha1, see Sha1Matches.
+func htmlLocations(r Report) (locations []htmlLocation) {
+	matches := func(violations []Violation, sha1 string, location string) bool {
+		for _, v := range violations {
+			if v.Location == location && Sha1Matches(sha1, v.Sha1) {
+				return true
+			}
+		}
+		return false
+	}
+
+	for _, v := range r.Audited {
+		status := statusOK
+		if matches(r.Violations, v.Sha1, v.Location) {
+			status = statusViolation
+		} else if matches(r.Waived, v.Sha1, v.Location) {
+			status = statusWaived
+		}
+		locations = append(locations, htmlLocation{Sha1: v.Sha1, Location: v.Location, Status: status})
+	}
+	sort.SliceStable(locations, func(i, j int) bool {
+		return locations[i].Location < locations[j].Location
+	})
+	return
+}
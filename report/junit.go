@@ -0,0 +1,249 @@
+//
+// Copyright © 2020-present Sonatype Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+package report
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+// junitClassname is the classname of every testcase, so CI systems group them together
+const junitClassname = "hashbrowns"
+
+type junitTestSuites struct {
+	XMLName  xml.Name         `xml:"testsuites"`
+	Name     string           `xml:"name,attr"`
+	Tests    int              `xml:"tests,attr"`
+	Failures int              `xml:"failures,attr"`
+	Errors   int              `xml:"errors,attr"`
+	Skipped  int              `xml:"skipped,attr"`
+	Suites   []junitTestSuite `xml:"testsuite"`
+}
+
+type junitTestSuite struct {
+	Name       string          `xml:"name,attr"`
+	Tests      int             `xml:"tests,attr"`
+	Failures   int             `xml:"failures,attr"`
+	Errors     int             `xml:"errors,attr"`
+	Skipped    int             `xml:"skipped,attr"`
+	Properties []junitProperty `xml:"properties>property,omitempty"`
+	Cases      []junitTestCase `xml:"testcase"`
+}
+
+type junitProperty struct {
+	Name  string `xml:"name,attr"`
+	Value string `xml:"value,attr"`
+}
+
+type junitTestCase struct {
+	Classname string        `xml:"classname,attr"`
+	Name      string        `xml:"name,attr"`
+	Failure   *junitMessage `xml:"failure,omitempty"`
+	Error     *junitMessage `xml:"error,omitempty"`
+	Skipped   *junitMessage `xml:"skipped,omitempty"`
+}
+
+type junitMessage struct {
+	Message string `xml:"message,attr"`
+	Type    string `xml:"type,attr,omitempty"`
+	Text    string `xml:",cdata"`
+}
+
+// WriteJUnit writes reports as a JUnit XML document, with a testsuite for each report. Each suite has a testcase for
+// the policy evaluation as a whole, and one for each component that violated (or had a waived violation of) policy.
+func WriteJUnit(w io.Writer, reports []Report) error {
+	suites := junitTestSuites{Name: junitClassname}
+	for _, r := range reports {
+		suite := junitSuite(r)
+		suites.Tests += suite.Tests
+		suites.Failures += suite.Failures
+		suites.Errors += suite.Errors
+		suites.Skipped += suite.Skipped
+		suites.Suites = append(suites.Suites, suite)
+	}
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	encoder := xml.NewEncoder(w)
+	encoder.Indent("", "  ")
+	if err := encoder.Encode(suites); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
+
+func junitSuite(r Report) junitTestSuite {
+	name := r.Application
+	if name == "" {
+		name = r.Path
+	}
+	if r.Stage != "" {
+		name = fmt.Sprintf("%s (%s)", name, r.Stage)
+	}
+	suite := junitTestSuite{Name: name}
+	for _, p := range []junitProperty{
+		{Name: "application", Value: r.Application},
+		{Name: "stage", Value: r.Stage},
+		{Name: "path", Value: r.Path},
+		{Name: "backend", Value: r.Backend},
+		{Name: "entries", Value: fmt.Sprint(r.Entries)},
+		{Name: "reportUrl", Value: r.ReportURL},
+	} {
+		if p.Value != "" {
+			suite.Properties = append(suite.Properties, p)
+		}
+	}
+	if r.ViolationsUnconfirmed {
+		suite.Properties = append(suite.Properties, junitProperty{Name: "violationsUnconfirmed", Value: "true"})
+	}
+
+	summary := junitTestCase{Classname: junitClassname, Name: "Policy evaluation"}
+	switch r.Outcome {
+	case OutcomeError:
+		summary.Error = &junitMessage{Message: r.Error, Type: OutcomeError, Text: r.Error}
+	case OutcomeSkipped:
+		summary.Skipped = &junitMessage{Message: r.Summary()}
+	case OutcomeFailure:
+		summary.Failure = &junitMessage{
+			Message: fmt.Sprintf("%d component(s) violate policy", len(groupViolations(r.Violations))),
+			Type:    OutcomeFailure,
+			Text:    noteText(r) + reportURLText(r),
+		}
+	}
+	suite.Cases = append(suite.Cases, summary)
+
+	for _, group := range groupViolations(r.Violations) {
+		suite.Cases = append(suite.Cases, junitTestCase{
+			Classname: junitClassname,
+			Name:      componentName(group[0]),
+			Failure: &junitMessage{
+				Message: violationsMessage(group),
+				Type:    group[0].Severity,
+				Text:    violationsText(group) + noteText(r) + reportURLText(r),
+			},
+		})
+	}
+	for _, group := range groupViolations(r.Waived) {
+		suite.Cases = append(suite.Cases, junitTestCase{
+			Classname: junitClassname,
+			Name:      componentName(group[0]),
+			Skipped:   &junitMessage{Message: "Waived: " + group[0].Waiver},
+		})
+	}
+
+	suite.Tests = len(suite.Cases)
+	for _, c := range suite.Cases {
+		switch {
+		case c.Failure != nil:
+			suite.Failures++
+		case c.Error != nil:
+			suite.Errors++
+		case c.Skipped != nil:
+			suite.Skipped++
+		}
+	}
+	return suite
+}
+
+// groupViolations groups violations by component, in the order each component first appears, with the most severe
+// violation of each component first
+func groupViolations(violations []Violation) (groups [][]Violation) {
+	index := map[string]int{}
+	for _, v := range violations {
+		key := v.Sha1 + "\x00" + v.Location
+		i, ok := index[key]
+		if !ok {
+			i = len(groups)
+			index[key] = i
+			groups = append(groups, nil)
+		}
+		groups[i] = append(groups[i], v)
+	}
+	for _, group := range groups {
+		sort.SliceStable(group, func(i, j int) bool {
+			return severityRank(group[i].Severity) > severityRank(group[j].Severity)
+		})
+	}
+	return
+}
+
+func severityRank(severity string) int {
+	switch severity {
+	case "critical":
+		return 4
+	case "high":
+		return 3
+	case "medium":
+		return 2
+	case "low":
+		return 1
+	}
+	return 0
+}
+
+func componentName(v Violation) string {
+	if v.Location == "" {
+		return v.Sha1
+	}
+	return fmt.Sprintf("%s (%s)", v.Location, v.Sha1)
+}
+
+func violationsMessage(group []Violation) string {
+	var reasons []string
+	for _, v := range group {
+		if v.Reason != "" {
+			reasons = append(reasons, v.Reason)
+		}
+	}
+	if len(reasons) == 0 {
+		return "Violates policy"
+	}
+	return strings.Join(reasons, ", ")
+}
+
+func violationsText(group []Violation) string {
+	var b strings.Builder
+	for _, v := range group {
+		if v.Reason != "" {
+			fmt.Fprintf(&b, "Policy: %s\n", v.Reason)
+		}
+		fmt.Fprintf(&b, "Severity: %s\n", v.Severity)
+		if v.ThreatLevel > 0 {
+			fmt.Fprintf(&b, "Threat level: %d\n", v.ThreatLevel)
+		}
+	}
+	fmt.Fprintf(&b, "Sha1: %s\nLocation: %s\n", group[0].Sha1, group[0].Location)
+	return b.String()
+}
+
+func noteText(r Report) string {
+	if note := r.ViolationsNote(); note != "" {
+		return note + "\n"
+	}
+	return ""
+}
+
+func reportURLText(r Report) string {
+	if r.ReportURL == "" {
+		return ""
+	}
+	return "Report URL: " + r.ReportURL + "\n"
+}
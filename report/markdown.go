@@ -0,0 +1,125 @@
+//
+// Copyright © 2020-present Sonatype Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+package report
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+// markdownTopViolations is how many violations are listed in a markdown summary, the most severe first
+const markdownTopViolations = 10
+
+var markdownSeverities = []string{"critical", "high", "medium", "low"}
+
+var markdownIcons = map[string]string{
+	OutcomePass:    ":white_check_mark:",
+	OutcomeSkipped: ":fast_forward:",
+	OutcomeFailure: ":x:",
+	OutcomeError:   ":warning:",
+}
+
+// WriteMarkdown writes reports as a compact markdown summary, for posting as a pull request comment. Each report has
+// a table of counts by severity, its most severe violations, and a link to the full report.
+func WriteMarkdown(w io.Writer, reports []Report) error {
+	var b strings.Builder
+	for i, r := range reports {
+		if i > 0 {
+			b.WriteString("\n")
+		}
+		writeMarkdownReport(&b, r)
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+func writeMarkdownReport(b *strings.Builder, r Report) {
+	name := r.Application
+	if name == "" {
+		name = r.Path
+	}
+	if r.Stage != "" {
+		name = fmt.Sprintf("%s (%s)", name, r.Stage)
+	}
+	fmt.Fprintf(b, "### %s Hashbrowns: %s\n\n", markdownIcons[r.Outcome], markdownCell(name))
+
+	if r.Outcome == OutcomeError {
+		fmt.Fprintf(b, "The audit could not be done: %s\n", markdownCell(r.Error))
+		return
+	}
+
+	counts := map[string]int{}
+	for _, v := range r.Violations {
+		counts[v.Severity]++
+	}
+	policyAction := r.PolicyAction
+	if policyAction == "" {
+		policyAction = "-"
+	}
+
+	b.WriteString("| Result | Policy action | Entries | Critical | High | Medium | Low | Waived |\n")
+	b.WriteString("|---|---|---:|---:|---:|---:|---:|---:|\n")
+	fmt.Fprintf(b, "| %s | %s | %d |", r.Summary(), markdownCell(policyAction), r.Entries)
+	for _, severity := range markdownSeverities {
+		fmt.Fprintf(b, " %d |", counts[severity])
+	}
+	fmt.Fprintf(b, " %d |\n", len(r.Waived))
+
+	if len(r.Violations) > 0 {
+		top := append([]Violation{}, r.Violations...)
+		sort.SliceStable(top, func(i, j int) bool {
+			if top[i].ThreatLevel != top[j].ThreatLevel {
+				return top[i].ThreatLevel > top[j].ThreatLevel
+			}
+			return severityRank(top[i].Severity) > severityRank(top[j].Severity)
+		})
+
+		b.WriteString("\n| Severity | Policy | Location | Sha1 |\n")
+		b.WriteString("|---|---|---|---|\n")
+		for i, v := range top {
+			if i == markdownTopViolations {
+				break
+			}
+			severity := v.Severity
+			if v.ThreatLevel > 0 {
+				severity = fmt.Sprintf("%s (%d)", v.Severity, v.ThreatLevel)
+			}
+			fmt.Fprintf(b, "| %s | %s | `%s` | `%s` |\n", severity, markdownCell(v.Reason), markdownCode(v.Location), markdownCode(v.Sha1))
+		}
+		if len(top) > markdownTopViolations {
+			fmt.Fprintf(b, "\n_and %d more_\n", len(top)-markdownTopViolations)
+		}
+		if note := r.ViolationsNote(); note != "" {
+			fmt.Fprintf(b, "\n_%s_\n", note)
+		}
+	}
+
+	if r.ReportURL != "" {
+		fmt.Fprintf(b, "\n[Full report](%s)\n", r.ReportURL)
+	}
+}
+
+// markdownCell escapes s so it stays in its table cell, and on one line
+func markdownCell(s string) string {
+	return strings.NewReplacer("|", `\|`, "\r", " ", "\n", " ").Replace(s)
+}
+
+// markdownCode makes s safe to put in a code span in a table cell
+func markdownCode(s string) string {
+	return markdownCell(strings.ReplaceAll(s, "`", "'"))
+}
@@ -0,0 +1,124 @@
+//
+// Copyright © 2020-present Sonatype Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+// Package report has the result of an audit, in a form that can be written out for people and other tools
+package report
+
+import (
+	"encoding/json"
+	"io"
+	"strings"
+
+	"github.com/sonatype-nexus-community/go-sona-types/cyclonedx"
+)
+
+// The outcome of an audit, from best to worst
+const (
+	OutcomePass    = "pass"
+	OutcomeSkipped = "skipped"
+	OutcomeFailure = "failure"
+	OutcomeError   = "error"
+)
+
+// Report is the structured result of auditing one list of sha1s against one application
+type Report struct {
+	Backend      string `json:"backend,omitempty"`
+	Application  string `json:"application"`
+	Stage        string `json:"stage"`
+	Path         string `json:"path,omitempty"`
+	Entries      int    `json:"entries"`
+	Outcome      string `json:"outcome"`
+	PolicyAction string `json:"policyAction,omitempty"`
+	ReportURL    string `json:"reportUrl,omitempty"`
+	Error        string `json:"error,omitempty"`
+
+	Violations []Violation `json:"violations,omitempty"`
+	Waived     []Violation `json:"waived,omitempty"`
+
+	// ViolationsUnconfirmed is set when the backend can't say which violations failed the audit, so Violations is
+	// every violation that hasn't been waived, and some of them may only be warnings
+	ViolationsUnconfirmed bool `json:"violationsUnconfirmed,omitempty"`
+
+	// Audited is every entry that was audited, only kept for outputs that list them all
+	Audited []cyclonedx.Sha1SBOM `json:"-"`
+}
+
+// Violation is a single entry that caused an audit to fail, and why
+type Violation struct {
+	Sha1        string `json:"sha1"`
+	Location    string `json:"location"`
+	Reason      string `json:"reason,omitempty"`
+	Severity    string `json:"severity,omitempty"`
+	ThreatLevel int    `json:"threatLevel,omitempty"`
+	Waiver      string `json:"waiver,omitempty"`
+}
+
+// Sha1Matches reports whether sha1 is the one reported by a policy report or waiver. Nexus IQ Server shortens the
+// hashes in its policy reports, so sha1 matches any reported hash it starts with, ignoring case.
+func Sha1Matches(sha1 string, reported string) bool {
+	return reported != "" && strings.HasPrefix(strings.ToLower(sha1), strings.ToLower(reported))
+}
+
+// unconfirmedNote is shown alongside violations when the backend can't say which of them failed the audit
+const unconfirmedNote = "Which of these violations failed the audit isn't known, so every violation that hasn't been waived is listed, and some may only be warnings"
+
+// ViolationsNote returns a caveat to show alongside the violations of r, or an empty string if they all failed it
+func (r Report) ViolationsNote() string {
+	if r.ViolationsUnconfirmed && len(r.Violations) > 0 {
+		return unconfirmedNote
+	}
+	return ""
+}
+
+// ExitCode returns 2 if the audit had an error, 1 if it failed policy, or 0 if all is well
+func (r Report) ExitCode() int {
+	switch r.Outcome {
+	case OutcomeError:
+		return 2
+	case OutcomeFailure:
+		return 1
+	}
+	return 0
+}
+
+// Summary describes the outcome of r in a few words
+func (r Report) Summary() string {
+	switch r.Outcome {
+	case OutcomeError:
+		return "Error: " + r.Error
+	case OutcomeSkipped:
+		return "Skipped, nothing new"
+	case OutcomeFailure:
+		return "Failure"
+	}
+	return "Pass"
+}
+
+// WriteJSON writes r as an indented JSON document
+func (r Report) WriteJSON(w io.Writer) error {
+	return writeJSON(w, r)
+}
+
+// WriteJSON writes reports as an indented JSON list, empty rather than null if there are none
+func WriteJSON(w io.Writer, reports []Report) error {
+	return writeJSON(w, append([]Report{}, reports...))
+}
+
+func writeJSON(w io.Writer, v interface{}) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(v)
+}
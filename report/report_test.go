@@ -0,0 +1,128 @@
+//
+// Copyright © 2020-present Sonatype Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+package report
+
+import (
+	"bytes"
+	"io/ioutil"
+	"testing"
+	"time"
+
+	"github.com/sonatype-nexus-community/go-sona-types/cyclonedx"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExitCodeAndSummary(t *testing.T) {
+	assert.Equal(t, 0, Report{Outcome: OutcomePass}.ExitCode())
+	assert.Equal(t, 0, Report{Outcome: OutcomeSkipped}.ExitCode())
+	assert.Equal(t, 1, Report{Outcome: OutcomeFailure}.ExitCode())
+	assert.Equal(t, 2, Report{Outcome: OutcomeError, Error: "boom"}.ExitCode())
+
+	assert.Equal(t, "Pass", Report{Outcome: OutcomePass}.Summary())
+	assert.Equal(t, "Error: boom", Report{Outcome: OutcomeError, Error: "boom"}.Summary())
+}
+
+func TestSha1Matches(t *testing.T) {
+	assert.True(t, Sha1Matches("9987ca4f73d5ea0e534dfbf19238552df4de507e", "9987ca4f73d5ea0e534d"))
+	assert.True(t, Sha1Matches("9987CA4F73D5EA0E534DFBF19238552DF4DE507E", "9987ca4f73d5ea0e534d"))
+	assert.False(t, Sha1Matches("2a72a07fbc9de22308d12a32f7d33504349e63c9", "9987ca4f73d5ea0e534d"))
+	assert.False(t, Sha1Matches("9987ca4f73d5ea0e534dfbf19238552df4de507e", ""))
+}
+
+func TestWriteJSON(t *testing.T) {
+	buf := new(bytes.Buffer)
+	r := Report{Application: "testapp", Stage: "develop", Entries: 2, Outcome: OutcomeFailure, PolicyAction: "Failure", ReportURL: "http://iq/report"}
+
+	assert.NoError(t, r.WriteJSON(buf))
+	assert.JSONEq(t, `{
+		"application": "testapp",
+		"stage": "develop",
+		"entries": 2,
+		"outcome": "failure",
+		"policyAction": "Failure",
+		"reportUrl": "http://iq/report"
+	}`, buf.String())
+
+	buf.Reset()
+	assert.NoError(t, WriteJSON(buf, nil))
+	assert.Equal(t, "[]\n", buf.String())
+}
+
+var auditedReports = []Report{
+	{
+		Application:           "testapp",
+		Stage:                 "build",
+		Entries:               3,
+		Outcome:               OutcomeFailure,
+		ReportURL:             "http://iq/report",
+		ViolationsUnconfirmed: true,
+		Violations: []Violation{
+			{Sha1: "9987ca4f", Location: "lib/foo.jar", Reason: "Security-Medium", Severity: "medium", ThreatLevel: 5},
+			{Sha1: "9987ca4f", Location: "lib/foo.jar", Reason: "Security-Critical", Severity: "critical", ThreatLevel: 10},
+		},
+		Waived: []Violation{
+			{Sha1: "da39a3ee", Location: "lib/bar.jar", Reason: "License-Banned", Severity: "high", Waiver: "Removed next release"},
+		},
+		Audited: []cyclonedx.Sha1SBOM{
+			{Sha1: "da39a3ee5e6b4b0d3255bfef95601890afd80709", Location: "lib/bar.jar"},
+			{Sha1: "9987ca4f73d5ea0e534dfbf19238552df4de507e", Location: "lib/foo.jar"},
+			{Sha1: "2a72a07fbc9de22308d12a32f7d33504349e63c9", Location: "lib/<script>.jar"},
+		},
+	},
+	{Application: "otherapp", Stage: "build", Outcome: OutcomeError, Error: "Unable to reach Nexus IQ Server"},
+}
+
+func TestWriteJUnit(t *testing.T) {
+	buf := new(bytes.Buffer)
+	assert.NoError(t, WriteJUnit(buf, auditedReports))
+
+	expected, err := ioutil.ReadFile("testdata/junit.xml")
+	assert.NoError(t, err)
+	assert.Equal(t, string(expected), buf.String())
+}
+
+func TestWriteSARIF(t *testing.T) {
+	buf := new(bytes.Buffer)
+	assert.NoError(t, WriteSARIF(buf, auditedReports))
+
+	expected, err := ioutil.ReadFile("testdata/sarif.json")
+	assert.NoError(t, err)
+	assert.JSONEq(t, string(expected), buf.String())
+}
+
+func TestArtifactURI(t *testing.T) {
+	assert.Equal(t, "lib/foo.jar", artifactURI("lib/foo.jar"))
+	assert.Equal(t, "file:///opt/app/lib/foo%20bar.jar", artifactURI("/opt/app/lib/foo bar.jar"))
+	assert.Equal(t, "file:///C:/build/lib/qux.dll", artifactURI(`C:\build\lib\qux.dll`))
+}
+
+func TestWriteHTML(t *testing.T) {
+	buf := new(bytes.Buffer)
+	assert.NoError(t, WriteHTML(buf, auditedReports, time.Date(2020, 11, 2, 15, 4, 5, 0, time.UTC)))
+
+	expected, err := ioutil.ReadFile("testdata/report.html")
+	assert.NoError(t, err)
+	assert.Equal(t, string(expected), buf.String())
+}
+
+func TestWriteMarkdown(t *testing.T) {
+	buf := new(bytes.Buffer)
+	assert.NoError(t, WriteMarkdown(buf, auditedReports))
+
+	expected, err := ioutil.ReadFile("testdata/summary.md")
+	assert.NoError(t, err)
+	assert.Equal(t, string(expected), buf.String())
+}
@@ -0,0 +1,251 @@
+//
+// Copyright © 2020-present Sonatype Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+package report
+
+import (
+	"fmt"
+	"io"
+	"net/url"
+	"strings"
+
+	"github.com/sonatype-nexus-community/hashbrowns/buildversion"
+)
+
+const (
+	sarifSchema  = "https://json.schemastore.org/sarif-2.1.0.json"
+	sarifVersion = "2.1.0"
+	// sarifDefaultRule is the rule of violations that don't say which policy they broke
+	sarifDefaultRule = "policy-violation"
+)
+
+type sarifLog struct {
+	Schema  string     `json:"$schema"`
+	Version string     `json:"version"`
+	Runs    []sarifRun `json:"runs"`
+}
+
+type sarifRun struct {
+	Tool              sarifTool              `json:"tool"`
+	AutomationDetails sarifAutomationDetails `json:"automationDetails"`
+	Invocations       []sarifInvocation      `json:"invocations"`
+	Results           []sarifResult          `json:"results"`
+	Properties        map[string]interface{} `json:"properties,omitempty"`
+}
+
+type sarifTool struct {
+	Driver sarifDriver `json:"driver"`
+}
+
+type sarifDriver struct {
+	Name           string      `json:"name"`
+	Version        string      `json:"version"`
+	InformationURI string      `json:"informationUri"`
+	Rules          []sarifRule `json:"rules"`
+}
+
+type sarifRule struct {
+	ID                   string                 `json:"id"`
+	Name                 string                 `json:"name"`
+	ShortDescription     sarifMessage           `json:"shortDescription"`
+	DefaultConfiguration sarifConfiguration     `json:"defaultConfiguration"`
+	Properties           map[string]interface{} `json:"properties,omitempty"`
+}
+
+type sarifConfiguration struct {
+	Level string `json:"level"`
+}
+
+type sarifAutomationDetails struct {
+	ID string `json:"id"`
+}
+
+type sarifInvocation struct {
+	ExecutionSuccessful        bool                `json:"executionSuccessful"`
+	ToolExecutionNotifications []sarifNotification `json:"toolExecutionNotifications,omitempty"`
+}
+
+type sarifNotification struct {
+	Level   string       `json:"level"`
+	Message sarifMessage `json:"message"`
+}
+
+type sarifMessage struct {
+	Text string `json:"text"`
+}
+
+type sarifResult struct {
+	RuleID       string                 `json:"ruleId"`
+	RuleIndex    int                    `json:"ruleIndex"`
+	Level        string                 `json:"level"`
+	Message      sarifMessage           `json:"message"`
+	Locations    []sarifLocation        `json:"locations,omitempty"`
+	Fingerprints map[string]string      `json:"partialFingerprints,omitempty"`
+	Suppressions []sarifSuppression     `json:"suppressions,omitempty"`
+	Properties   map[string]interface{} `json:"properties,omitempty"`
+}
+
+type sarifLocation struct {
+	PhysicalLocation sarifPhysicalLocation `json:"physicalLocation"`
+}
+
+type sarifPhysicalLocation struct {
+	ArtifactLocation sarifArtifactLocation `json:"artifactLocation"`
+}
+
+type sarifArtifactLocation struct {
+	URI string `json:"uri"`
+}
+
+type sarifSuppression struct {
+	Kind          string `json:"kind"`
+	Justification string `json:"justification,omitempty"`
+}
+
+// WriteSARIF writes reports as a SARIF 2.1.0 log, with a run for each report. Each policy violation is a result at
+// the location of the file that violated it, with a rule for each policy. Waived violations are suppressed results.
+func WriteSARIF(w io.Writer, reports []Report) error {
+	sarif := sarifLog{Schema: sarifSchema, Version: sarifVersion, Runs: []sarifRun{}}
+	for _, r := range reports {
+		sarif.Runs = append(sarif.Runs, sarifRunOf(r))
+	}
+	return writeJSON(w, sarif)
+}
+
+func sarifRunOf(r Report) sarifRun {
+	run := sarifRun{
+		Tool: sarifTool{Driver: sarifDriver{
+			Name:           "hashbrowns",
+			Version:        buildversion.BuildVersion,
+			InformationURI: "https://github.com/sonatype-nexus-community/hashbrowns",
+			Rules:          []sarifRule{},
+		}},
+		AutomationDetails: sarifAutomationDetails{ID: fmt.Sprintf("hashbrowns/%s/%s/", r.Application, r.Stage)},
+		Invocations:       []sarifInvocation{{ExecutionSuccessful: r.Outcome != OutcomeError}},
+		Results:           []sarifResult{},
+	}
+	if r.Outcome == OutcomeError {
+		run.Invocations[0].ToolExecutionNotifications = []sarifNotification{{Level: "error", Message: sarifMessage{Text: r.Error}}}
+	}
+	run.Properties = map[string]interface{}{"outcome": r.Outcome}
+	if r.ReportURL != "" {
+		run.Properties["reportUrl"] = r.ReportURL
+	}
+	if note := r.ViolationsNote(); note != "" {
+		run.Properties["violationsUnconfirmed"] = true
+		run.Invocations[0].ToolExecutionNotifications = []sarifNotification{{Level: "note", Message: sarifMessage{Text: note}}}
+	}
+
+	rules := map[string]int{}
+	add := func(v Violation, suppression *sarifSuppression) {
+		id := v.Reason
+		if id == "" {
+			id = sarifDefaultRule
+		}
+		index, ok := rules[id]
+		if !ok {
+			index = len(run.Tool.Driver.Rules)
+			rules[id] = index
+			run.Tool.Driver.Rules = append(run.Tool.Driver.Rules, sarifRule{
+				ID:                   id,
+				Name:                 id,
+				ShortDescription:     sarifMessage{Text: id},
+				DefaultConfiguration: sarifConfiguration{Level: sarifLevel(v.Severity)},
+				Properties:           map[string]interface{}{"security-severity": securitySeverity(v)},
+			})
+		}
+
+		result := sarifResult{
+			RuleID:       id,
+			RuleIndex:    index,
+			Level:        sarifLevel(v.Severity),
+			Message:      sarifMessage{Text: sarifText(v, r.ReportURL)},
+			Fingerprints: map[string]string{"sha1": v.Sha1},
+			Properties:   map[string]interface{}{"sha1": v.Sha1, "severity": v.Severity},
+		}
+		if v.ThreatLevel > 0 {
+			result.Properties["threatLevel"] = v.ThreatLevel
+		}
+		if v.Location != "" {
+			result.Locations = []sarifLocation{{PhysicalLocation: sarifPhysicalLocation{
+				ArtifactLocation: sarifArtifactLocation{URI: artifactURI(v.Location)},
+			}}}
+		}
+		if suppression != nil {
+			result.Suppressions = []sarifSuppression{*suppression}
+		}
+		run.Results = append(run.Results, result)
+	}
+
+	for _, v := range r.Violations {
+		add(v, nil)
+	}
+	for _, v := range r.Waived {
+		add(v, &sarifSuppression{Kind: "external", Justification: v.Waiver})
+	}
+	return run
+}
+
+// sarifLevel maps a severity to the SARIF level dashboards use to decide how loudly to show a result
+func sarifLevel(severity string) string {
+	switch severity {
+	case "critical", "high":
+		return "error"
+	case "medium":
+		return "warning"
+	}
+	return "note"
+}
+
+// securitySeverity is the 0 to 10 score code scanning dashboards sort by, the threat level if there is one
+func securitySeverity(v Violation) string {
+	if v.ThreatLevel > 0 {
+		return fmt.Sprintf("%d.0", v.ThreatLevel)
+	}
+	switch v.Severity {
+	case "critical":
+		return "9.0"
+	case "high":
+		return "7.0"
+	case "medium":
+		return "4.0"
+	}
+	return "1.0"
+}
+
+func sarifText(v Violation, reportURL string) string {
+	text := fmt.Sprintf("%s violates policy", v.Sha1)
+	if v.Reason != "" {
+		text = fmt.Sprintf("%s violates policy %s", v.Sha1, v.Reason)
+	}
+	if reportURL != "" {
+		text += ", see " + reportURL
+	}
+	return text
+}
+
+// artifactURI turns a location into a URI reference, relative if the location is, with Windows paths made to look like
+// everything else
+func artifactURI(location string) string {
+	location = strings.ReplaceAll(location, `\`, "/")
+	if len(location) > 1 && location[1] == ':' {
+		location = "/" + location
+	}
+	u := url.URL{Path: location}
+	if strings.HasPrefix(location, "/") {
+		u.Scheme = "file"
+	}
+	return u.String()
+}
@@ -0,0 +1,332 @@
+//
+// Copyright © 2020-present Sonatype Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+// Package server has a small REST API that queues lists of sha1s (or SBOMs) to be audited, and hands back the results
+package server
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/sonatype-nexus-community/go-sona-types/cyclonedx"
+	"github.com/sonatype-nexus-community/hashbrowns/logger"
+	"github.com/sonatype-nexus-community/hashbrowns/parse"
+	"github.com/sonatype-nexus-community/hashbrowns/report"
+)
+
+// AuditsPath is where jobs are submitted, and where each job can be found by ID
+const AuditsPath = "/api/v1/audits"
+
+// The status of a job, in the order it goes through them
+const (
+	StatusQueued    = "queued"
+	StatusRunning   = "running"
+	StatusDone      = "done"
+	StatusCancelled = "cancelled"
+)
+
+var log = logger.Logger()
+
+// AuditFunc audits sha1s against an application and stage, and returns the result
+type AuditFunc func(sha1s []cyclonedx.Sha1SBOM, application string, stage string) report.Report
+
+// Options configures the queue and limits of a Server
+type Options struct {
+	// Concurrency is how many jobs are audited at the same time
+	Concurrency int
+	// QueueSize is how many jobs can be waiting, further submissions are turned away until there is room
+	QueueSize int
+	// MaxBodySize is the largest hash list or SBOM accepted, in bytes
+	MaxBodySize int64
+	// MaxFinishedJobs is how many finished jobs are kept around for their results to be fetched, at least 1
+	MaxFinishedJobs int
+	// DefaultStage is used for jobs submitted without a stage
+	DefaultStage string
+}
+
+// Job is a single submission, and its result once it is done
+type Job struct {
+	ID          string         `json:"id"`
+	Status      string         `json:"status"`
+	Application string         `json:"application"`
+	Stage       string         `json:"stage"`
+	Entries     int            `json:"entries"`
+	Created     time.Time      `json:"created"`
+	Finished    *time.Time     `json:"finished,omitempty"`
+	Result      *report.Report `json:"result,omitempty"`
+
+	sha1s []cyclonedx.Sha1SBOM
+}
+
+// Server is an http.Handler for the audits API, with a pool of workers auditing queued jobs
+type Server struct {
+	opts  Options
+	audit AuditFunc
+	queue chan *Job
+	wg    sync.WaitGroup
+
+	mu       sync.Mutex
+	closed   bool
+	jobs     map[string]*Job
+	finished []string
+}
+
+type errorResponse struct {
+	Error string `json:"error"`
+}
+
+// New returns a Server that audits jobs with audit, and starts its workers
+func New(audit AuditFunc, opts Options) *Server {
+	if opts.Concurrency < 1 {
+		opts.Concurrency = 1
+	}
+	if opts.QueueSize < 0 {
+		opts.QueueSize = 0
+	}
+	if opts.MaxFinishedJobs < 1 {
+		opts.MaxFinishedJobs = 1
+	}
+
+	s := &Server{
+		opts:  opts,
+		audit: audit,
+		queue: make(chan *Job, opts.QueueSize),
+		jobs:  map[string]*Job{},
+	}
+	for i := 0; i < opts.Concurrency; i++ {
+		s.wg.Add(1)
+		go s.work()
+	}
+	return s
+}
+
+// Close stops taking jobs, cancels the ones still queued, and waits for the ones already being audited to finish
+func (s *Server) Close() {
+	s.mu.Lock()
+	s.closed = true
+	close(s.queue)
+	s.mu.Unlock()
+
+	s.wg.Wait()
+}
+
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	log.WithFields(logrus.Fields{
+		"method": r.Method,
+		"path":   r.URL.Path,
+	}).Info("Handling request")
+
+	switch {
+	case r.URL.Path == AuditsPath:
+		if r.Method != http.MethodPost {
+			writeError(w, http.StatusMethodNotAllowed, fmt.Sprintf("Method %s is not allowed, submit jobs with POST", r.Method))
+			return
+		}
+		s.submit(w, r)
+	case strings.HasPrefix(r.URL.Path, AuditsPath+"/"):
+		if r.Method != http.MethodGet {
+			writeError(w, http.StatusMethodNotAllowed, fmt.Sprintf("Method %s is not allowed, get jobs with GET", r.Method))
+			return
+		}
+		s.get(w, strings.TrimPrefix(r.URL.Path, AuditsPath+"/"))
+	default:
+		writeError(w, http.StatusNotFound, "Not found")
+	}
+}
+
+func (s *Server) submit(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	application := query.Get("application")
+	if application == "" {
+		writeError(w, http.StatusBadRequest, "Application not set, pass it with ?application=")
+		return
+	}
+	stage := query.Get("stage")
+	if stage == "" {
+		stage = s.opts.DefaultStage
+	}
+	format := query.Get("format")
+	if format == "" {
+		format = parse.FormatShasum
+	}
+
+	body, err := ioutil.ReadAll(http.MaxBytesReader(w, r.Body, s.opts.MaxBodySize))
+	if err != nil && isTooLarge(err) {
+		writeError(w, http.StatusRequestEntityTooLarge, fmt.Sprintf("Request body is larger than %d bytes", s.opts.MaxBodySize))
+		return
+	}
+	if err != nil {
+		writeError(w, http.StatusBadRequest, fmt.Sprintf("Unable to read request body: %v", err))
+		return
+	}
+
+	sha1s, err := parse.Input(bytes.NewReader(body), parse.Options{Format: format, CSVSha1Column: "sha1", CSVPathColumn: "path"})
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	id, err := newID()
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	job := &Job{
+		ID:          id,
+		Status:      StatusQueued,
+		Application: application,
+		Stage:       stage,
+		Entries:     len(sha1s),
+		Created:     time.Now().UTC(),
+		sha1s:       sha1s,
+	}
+
+	s.mu.Lock()
+	if s.closed {
+		s.mu.Unlock()
+		writeError(w, http.StatusServiceUnavailable, "Server is shutting down")
+		return
+	}
+	select {
+	case s.queue <- job:
+		s.jobs[id] = job
+	default:
+		s.mu.Unlock()
+		writeError(w, http.StatusServiceUnavailable, "Job queue is full, try again later")
+		return
+	}
+	snapshot := *job
+	s.mu.Unlock()
+
+	log.WithFields(logrus.Fields{
+		"id":          id,
+		"application": application,
+		"stage":       stage,
+		"entries":     len(sha1s),
+	}).Info("Queued job")
+
+	w.Header().Set("Location", AuditsPath+"/"+id)
+	writeJSON(w, http.StatusAccepted, snapshot)
+}
+
+func (s *Server) get(w http.ResponseWriter, id string) {
+	s.mu.Lock()
+	job, ok := s.jobs[id]
+	var snapshot Job
+	if ok {
+		snapshot = *job
+	}
+	s.mu.Unlock()
+
+	if !ok {
+		writeError(w, http.StatusNotFound, fmt.Sprintf("No job with ID %q", id))
+		return
+	}
+	writeJSON(w, http.StatusOK, snapshot)
+}
+
+func (s *Server) work() {
+	defer s.wg.Done()
+
+	for job := range s.queue {
+		s.mu.Lock()
+		if s.closed {
+			s.cancel(job)
+			s.mu.Unlock()
+			continue
+		}
+		job.Status = StatusRunning
+		s.mu.Unlock()
+
+		log.WithField("id", job.ID).Info("Beginning audit of job")
+		result := s.audit(job.sha1s, job.Application, job.Stage)
+		finished := time.Now().UTC()
+
+		s.mu.Lock()
+		job.Status = StatusDone
+		job.Finished = &finished
+		job.Result = &result
+		job.sha1s = nil
+		s.forgetOldJobs(job.ID)
+		s.mu.Unlock()
+		log.WithFields(logrus.Fields{
+			"id":      job.ID,
+			"outcome": result.Outcome,
+		}).Info("Finished audit of job")
+	}
+}
+
+// cancel finishes a queued job without auditing it, as the server is shutting down, s.mu must be held
+func (s *Server) cancel(job *Job) {
+	log.WithField("id", job.ID).Info("Cancelling queued job, as the server is shutting down")
+
+	finished := time.Now().UTC()
+	job.Status = StatusCancelled
+	job.Finished = &finished
+	job.Result = &report.Report{
+		Application: job.Application,
+		Stage:       job.Stage,
+		Entries:     job.Entries,
+		Outcome:     report.OutcomeError,
+		Error:       "Server shut down before the job was audited",
+	}
+	job.sha1s = nil
+	s.forgetOldJobs(job.ID)
+}
+
+// forgetOldJobs remembers that id has finished, and drops the oldest finished jobs once there are too many
+func (s *Server) forgetOldJobs(id string) {
+	s.finished = append(s.finished, id)
+	for len(s.finished) > s.opts.MaxFinishedJobs {
+		delete(s.jobs, s.finished[0])
+		s.finished = s.finished[1:]
+	}
+}
+
+func newID() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+func writeError(w http.ResponseWriter, status int, message string) {
+	writeJSON(w, status, errorResponse{Error: message})
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		log.WithField("error", err).Error("Unable to write response")
+	}
+}
+
+// isTooLarge reports whether err is the error http.MaxBytesReader returns once a body goes over its limit, which has
+// no type of its own to check for
+func isTooLarge(err error) bool {
+	return err.Error() == "http: request body too large"
+}
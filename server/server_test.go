@@ -0,0 +1,206 @@
+//
+// Copyright © 2020-present Sonatype Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sonatype-nexus-community/go-sona-types/cyclonedx"
+	"github.com/sonatype-nexus-community/hashbrowns/report"
+	"github.com/stretchr/testify/assert"
+)
+
+const shasum = "9987ca4f73d5ea0e534dfbf19238552df4de507e  lib/foo.jar\n2a72a07fbc9de22308d12a32f7d33504349e63c9  lib/bar.jar\n"
+
+var defaultOptions = Options{Concurrency: 1, QueueSize: 10, MaxBodySize: 1024, MaxFinishedJobs: 10, DefaultStage: "develop"}
+
+func passingAudit(sha1s []cyclonedx.Sha1SBOM, application string, stage string) report.Report {
+	return report.Report{Application: application, Stage: stage, Entries: len(sha1s), Outcome: report.OutcomePass}
+}
+
+func startServer(t *testing.T, audit AuditFunc, opts Options) *httptest.Server {
+	s := New(audit, opts)
+	ts := httptest.NewServer(s)
+	t.Cleanup(func() {
+		ts.Close()
+		s.Close()
+	})
+	return ts
+}
+
+func post(t *testing.T, ts *httptest.Server, query string, body string) (*http.Response, Job) {
+	resp, err := http.Post(ts.URL+AuditsPath+query, "text/plain", strings.NewReader(body))
+	assert.NoError(t, err)
+	defer resp.Body.Close()
+
+	var job Job
+	_ = json.NewDecoder(resp.Body).Decode(&job)
+	return resp, job
+}
+
+func get(t *testing.T, ts *httptest.Server, id string) (*http.Response, Job) {
+	resp, err := http.Get(ts.URL + AuditsPath + "/" + id)
+	assert.NoError(t, err)
+	defer resp.Body.Close()
+
+	var job Job
+	_ = json.NewDecoder(resp.Body).Decode(&job)
+	return resp, job
+}
+
+func TestSubmitAndGetResult(t *testing.T) {
+	ts := startServer(t, passingAudit, defaultOptions)
+
+	resp, job := post(t, ts, "?application=testapp", shasum)
+	assert.Equal(t, http.StatusAccepted, resp.StatusCode)
+	assert.Equal(t, AuditsPath+"/"+job.ID, resp.Header.Get("Location"))
+	assert.Equal(t, "testapp", job.Application)
+	assert.Equal(t, "develop", job.Stage)
+	assert.Equal(t, 2, job.Entries)
+
+	deadline := time.Now().Add(5 * time.Second)
+	for job.Status != StatusDone && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+		resp, job = get(t, ts, job.ID)
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+	}
+
+	assert.Equal(t, StatusDone, job.Status)
+	assert.NotNil(t, job.Finished)
+	assert.Equal(t, &report.Report{Application: "testapp", Stage: "develop", Entries: 2, Outcome: report.OutcomePass}, job.Result)
+}
+
+func TestSubmitSBOM(t *testing.T) {
+	ts := startServer(t, passingAudit, defaultOptions)
+
+	resp, job := post(t, ts, "?application=testapp&stage=release&format=cyclonedx",
+		`{"bomFormat":"CycloneDX","components":[{"name":"foo.jar","hashes":[{"alg":"SHA-1","content":"9987ca4f73d5ea0e534dfbf19238552df4de507e"}]}]}`)
+
+	assert.Equal(t, http.StatusAccepted, resp.StatusCode)
+	assert.Equal(t, "release", job.Stage)
+	assert.Equal(t, 1, job.Entries)
+}
+
+func TestSubmitBadRequests(t *testing.T) {
+	ts := startServer(t, passingAudit, defaultOptions)
+
+	resp, _ := post(t, ts, "", shasum)
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+
+	resp, _ = post(t, ts, "?application=testapp&format=cobol", shasum)
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+
+	resp, _ = post(t, ts, "?application=testapp", strings.Repeat(shasum, 100))
+	assert.Equal(t, http.StatusRequestEntityTooLarge, resp.StatusCode)
+}
+
+type errReader struct{}
+
+func (errReader) Read([]byte) (int, error) {
+	return 0, errors.New("connection reset")
+}
+
+func TestSubmitUnreadableBody(t *testing.T) {
+	s := New(passingAudit, defaultOptions)
+	t.Cleanup(s.Close)
+
+	w := httptest.NewRecorder()
+	s.ServeHTTP(w, httptest.NewRequest(http.MethodPost, AuditsPath+"?application=testapp", errReader{}))
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), "connection reset")
+}
+
+func TestSubmitQueueFull(t *testing.T) {
+	release := make(chan struct{})
+	blockingAudit := func(sha1s []cyclonedx.Sha1SBOM, application string, stage string) report.Report {
+		<-release
+		return passingAudit(sha1s, application, stage)
+	}
+	ts := startServer(t, blockingAudit, Options{Concurrency: 1, QueueSize: 1, MaxBodySize: 1024, MaxFinishedJobs: 10})
+	defer close(release)
+
+	// The first job is picked up by the only worker, the second waits in the queue, and the third is turned away
+	resp, _ := post(t, ts, "?application=testapp", shasum)
+	assert.Equal(t, http.StatusAccepted, resp.StatusCode)
+	time.Sleep(50 * time.Millisecond)
+	resp, _ = post(t, ts, "?application=testapp", shasum)
+	assert.Equal(t, http.StatusAccepted, resp.StatusCode)
+	resp, _ = post(t, ts, "?application=testapp", shasum)
+	assert.Equal(t, http.StatusServiceUnavailable, resp.StatusCode)
+}
+
+func TestCloseCancelsQueuedJobs(t *testing.T) {
+	started := make(chan struct{}, 2)
+	release := make(chan struct{})
+	blockingAudit := func(sha1s []cyclonedx.Sha1SBOM, application string, stage string) report.Report {
+		started <- struct{}{}
+		<-release
+		return passingAudit(sha1s, application, stage)
+	}
+	s := New(blockingAudit, Options{Concurrency: 1, QueueSize: 1, MaxBodySize: 1024, MaxFinishedJobs: 10})
+	ts := httptest.NewServer(s)
+	defer ts.Close()
+
+	_, running := post(t, ts, "?application=testapp", shasum)
+	<-started
+	_, queued := post(t, ts, "?application=testapp", shasum)
+
+	closed := make(chan struct{})
+	go func() {
+		s.Close()
+		close(closed)
+	}()
+	deadline := time.Now().Add(5 * time.Second)
+	for !isClosed(s) && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	// Close waits for the running job, but not for the queued one, which is never audited
+	close(release)
+	select {
+	case <-closed:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Close did not return once the running job finished")
+	}
+	assert.Equal(t, 0, len(started), "Queued job was audited after Close")
+
+	_, running = get(t, ts, running.ID)
+	assert.Equal(t, StatusDone, running.Status)
+	_, queued = get(t, ts, queued.ID)
+	assert.Equal(t, StatusCancelled, queued.Status)
+	assert.Equal(t, report.OutcomeError, queued.Result.Outcome)
+}
+
+func isClosed(s *Server) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.closed
+}
+
+func TestGetUnknownJob(t *testing.T) {
+	ts := startServer(t, passingAudit, defaultOptions)
+
+	resp, _ := get(t, ts, "nope")
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+}
@@ -15,6 +15,11 @@
 //
 package types
 
+import "time"
+
+// HashbrownsDirName is the directory in the user's home where hashbrowns keeps its files
+const HashbrownsDirName = ".hashbrowns"
+
 // Config is basic config for hashbrowns
 type Config struct {
 	LogLevel    int
@@ -25,4 +30,74 @@ type Config struct {
 	Application string
 	Stage       string
 	MaxRetries  int
+
+	// Decorative output
+	Quiet    bool
+	NoBanner bool
+
+	// Logging
+	LogFile       string
+	LogFormat     string
+	LogAppend     bool
+	TracePayloads bool
+
+	// Input parsing
+	InputFormat string
+	CSVSha1Col  string
+	CSVPathCol  string
+
+	// Built in hashing
+	ArchiveDepth  int
+	ImageTar      string
+	LibrariesOnly bool
+	CacheDir      string
+	ClearCache    bool
+
+	// Filtering of entries before submission
+	FilterPresets []string
+	FilterExts    []string
+	MinSize       int64
+	MaxSize       int64
+
+	// Rewriting of locations before submission
+	Excludes      []string
+	StripPrefixes []string
+	PathRewrites  []string
+	RedactPaths   string
+
+	// Incremental audits
+	Diff     bool
+	NewOnly  bool
+	StateDir string
+
+	// Backend to audit with, and its settings
+	Backend     string
+	DenyLists   []string
+	AllowLists  []string
+	MockOutcome string
+
+	// Waivers
+	WaiversFile string
+	WaiverMode  string
+
+	// Batch mode
+	Manifest    string
+	Concurrency int
+
+	// Watch mode
+	Debounce time.Duration
+
+	// Server mode
+	Listen          string
+	QueueSize       int
+	MaxBodySize     int64
+	MaxFinishedJobs int
+
+	// Dry runs
+	DryRun             bool
+	ResolveApplication bool
+
+	// Output of results
+	Outputs    []string
+	OutputFile string
 }
@@ -0,0 +1,163 @@
+//
+// Copyright © 2020-present Sonatype Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+// Package waiver reads waivers for known and accepted hashes or paths, and applies them to what is audited
+package waiver
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/sonatype-nexus-community/go-sona-types/cyclonedx"
+	"github.com/sonatype-nexus-community/hashbrowns/location"
+	"github.com/sonatype-nexus-community/hashbrowns/report"
+)
+
+// DefaultFile is the waiver file used when none is given, if it exists
+const DefaultFile = ".hashbrowns-ignore"
+
+// DateFormat is how expiry dates are written in a waiver file
+const DateFormat = "2006-01-02"
+
+var sha1Pattern = regexp.MustCompile(`^[0-9a-fA-F]{40}$`)
+
+// Waiver accepts the risk of a single sha1, or every path matching a glob, until it expires
+type Waiver struct {
+	Sha1          string
+	Glob          string
+	Expires       time.Time
+	Justification string
+	Line          int
+
+	glob *regexp.Regexp
+}
+
+// List is the waivers read from one file, split into those that still apply and those that have expired
+type List struct {
+	Path    string
+	Active  []Waiver
+	Expired []Waiver
+}
+
+func (w Waiver) String() string {
+	target := w.Sha1
+	if target == "" {
+		target = w.Glob
+	}
+	return fmt.Sprintf("%s  %s  %s", target, w.Expires.Format(DateFormat), w.Justification)
+}
+
+// Load reads the waiver file at path. Each line is a sha1 or path glob, the date the waiver expires on, and why it is
+// accepted, separated by whitespace. Blank lines and lines starting with # are skipped. Waivers are good until the end
+// of the day they expire on, compared to now.
+func Load(path string, now time.Time) (list *List, err error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return
+	}
+	defer file.Close()
+
+	list = &List{Path: path}
+	scanner := bufio.NewScanner(file)
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+
+		w, err := parseLine(text)
+		if err != nil {
+			return nil, fmt.Errorf("Unable to parse waiver on line %d of %s: %v", line, path, err)
+		}
+		w.Line = line
+
+		if now.Before(w.Expires.AddDate(0, 0, 1)) {
+			list.Active = append(list.Active, w)
+		} else {
+			list.Expired = append(list.Expired, w)
+		}
+	}
+
+	return list, scanner.Err()
+}
+
+func parseLine(text string) (w Waiver, err error) {
+	fields := strings.Fields(text)
+	if len(fields) < 3 {
+		return w, fmt.Errorf("expected a sha1 or path glob, an expiry date and a justification")
+	}
+
+	if w.Expires, err = time.Parse(DateFormat, fields[1]); err != nil {
+		return w, fmt.Errorf("expiry date %q is not in YYYY-MM-DD format", fields[1])
+	}
+	w.Justification = strings.Join(fields[2:], " ")
+
+	if sha1Pattern.MatchString(fields[0]) {
+		w.Sha1 = strings.ToLower(fields[0])
+		return
+	}
+	w.Glob = fields[0]
+	w.glob, err = location.Glob(w.Glob)
+	return
+}
+
+// Match returns the first active waiver for sha1 or location, if there is one. Sha1s from policy reports may be
+// shortened, see report.Sha1Matches.
+func (l *List) Match(sha1 string, location string) (Waiver, bool) {
+	if l == nil {
+		return Waiver{}, false
+	}
+
+	for _, w := range l.Active {
+		if w.Sha1 != "" && report.Sha1Matches(w.Sha1, sha1) {
+			return w, true
+		}
+		if w.glob != nil && w.glob.MatchString(location) {
+			return w, true
+		}
+	}
+	return Waiver{}, false
+}
+
+// Filter splits sha1s into those without an active waiver, and those with one
+func (l *List) Filter(sha1s []cyclonedx.Sha1SBOM) (kept []cyclonedx.Sha1SBOM, waived []cyclonedx.Sha1SBOM) {
+	for _, v := range sha1s {
+		if _, ok := l.Match(v.Sha1, v.Location); ok {
+			waived = append(waived, v)
+		} else {
+			kept = append(kept, v)
+		}
+	}
+	return
+}
+
+// ApplyToViolations splits violations into those without an active waiver, and those with one, each of which has the
+// justification of its waiver
+func (l *List) ApplyToViolations(violations []report.Violation) (remaining []report.Violation, waived []report.Violation) {
+	for _, v := range violations {
+		if w, ok := l.Match(v.Sha1, v.Location); ok {
+			v.Waiver = w.Justification
+			waived = append(waived, v)
+		} else {
+			remaining = append(remaining, v)
+		}
+	}
+	return
+}
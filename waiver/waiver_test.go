@@ -0,0 +1,95 @@
+//
+// Copyright © 2020-present Sonatype Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+package waiver
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/sonatype-nexus-community/go-sona-types/cyclonedx"
+	"github.com/sonatype-nexus-community/hashbrowns/report"
+	"github.com/stretchr/testify/assert"
+)
+
+var now = time.Date(2020, 12, 31, 18, 0, 0, 0, time.UTC)
+
+func load(t *testing.T) *List {
+	list, err := Load("testdata/hashbrowns-ignore", now)
+	assert.NoError(t, err)
+	return list
+}
+
+func TestLoad(t *testing.T) {
+	list := load(t)
+
+	assert.Equal(t, 2, len(list.Active))
+	assert.Equal(t, "9987ca4f73d5ea0e534dfbf19238552df4de507e", list.Active[0].Sha1)
+	assert.Equal(t, "Vendor fix due in Q1, see SEC-123", list.Active[0].Justification)
+	assert.Equal(t, "/opt/legacy/**/*.jar", list.Active[1].Glob)
+
+	assert.Equal(t, 1, len(list.Expired))
+	assert.Equal(t, 4, list.Expired[0].Line)
+	assert.Equal(t, "libssl.so.*  2020-01-31  Waiting on the base image update", list.Expired[0].String())
+}
+
+func TestLoadBadLine(t *testing.T) {
+	dir, err := ioutil.TempDir("", "waiver")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, DefaultFile)
+	assert.NoError(t, ioutil.WriteFile(path, []byte("\n*.jar  soon  Because\n"), 0644))
+
+	_, err = Load(path, now)
+	assert.Equal(t, "Unable to parse waiver on line 2 of "+path+": expiry date \"soon\" is not in YYYY-MM-DD format", err.Error())
+}
+
+func TestFilter(t *testing.T) {
+	kept, waived := load(t).Filter([]cyclonedx.Sha1SBOM{
+		{Sha1: "9987CA4F73D5EA0E534DFBF19238552DF4DE507E", Location: "lib/foo.jar"},
+		{Sha1: "2a72a07fbc9de22308d12a32f7d33504349e63c9", Location: "/opt/legacy/app/lib/bar.jar"},
+		{Sha1: "aaf4c61ddcc5e8a2dabede0f3b482cd9aea9434d", Location: "/opt/legacy/app/lib/bar.war"},
+		{Sha1: "da39a3ee5e6b4b0d3255bfef95601890afd80709", Location: "/usr/lib/libssl.so.1.1"},
+	})
+
+	assert.Equal(t, []cyclonedx.Sha1SBOM{
+		{Sha1: "aaf4c61ddcc5e8a2dabede0f3b482cd9aea9434d", Location: "/opt/legacy/app/lib/bar.war"},
+		{Sha1: "da39a3ee5e6b4b0d3255bfef95601890afd80709", Location: "/usr/lib/libssl.so.1.1"},
+	}, kept)
+	assert.Equal(t, 2, len(waived))
+}
+
+func TestApplyToViolations(t *testing.T) {
+	remaining, waived := load(t).ApplyToViolations([]report.Violation{
+		{Sha1: "9987ca4f73d5ea0e534d", Location: "lib/foo.jar", Reason: "Security-Critical"},
+		{Sha1: "da39a3ee5e6b4b0d3255", Location: "/usr/lib/libssl.so.1.1", Reason: "Security-High"},
+	})
+
+	assert.Equal(t, []report.Violation{{Sha1: "da39a3ee5e6b4b0d3255", Location: "/usr/lib/libssl.so.1.1", Reason: "Security-High"}}, remaining)
+	assert.Equal(t, []report.Violation{
+		{Sha1: "9987ca4f73d5ea0e534d", Location: "lib/foo.jar", Reason: "Security-Critical", Waiver: "Vendor fix due in Q1, see SEC-123"},
+	}, waived)
+}
+
+func TestNilListMatchesNothing(t *testing.T) {
+	var list *List
+
+	_, ok := list.Match("9987ca4f73d5ea0e534dfbf19238552df4de507e", "lib/foo.jar")
+	assert.False(t, ok)
+}
@@ -0,0 +1,150 @@
+//
+// Copyright © 2020-present Sonatype Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+// Package watch has functions for noticing when a file or directory tree changes
+package watch
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/sonatype-nexus-community/hashbrowns/logger"
+)
+
+var log = logger.Logger()
+
+// Options configures how changes are watched for
+type Options struct {
+	// Debounce is how long changes have to settle for before onChange is called
+	Debounce time.Duration
+	// Exclude are files and directories whose changes are ignored, such as hashbrowns' own cache and logs, which would
+	// otherwise set off another change every time they are written
+	Exclude []string
+	// OnReady, if set, is called once path is being watched
+	OnReady func()
+}
+
+// Path watches path, a single file or a directory tree, until stop is closed. Once changes have settled for
+// opts.Debounce, onChange is called, so a burst of changes such as a build writing many files only calls it once.
+func Path(path string, opts Options, stop <-chan struct{}, onChange func()) (err error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return
+	}
+
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return
+	}
+	defer watcher.Close()
+
+	excluded := excluder(opts.Exclude)
+	isDir := info.IsDir()
+	if isDir {
+		err = addTree(watcher, path, excluded)
+	} else {
+		// Watch the parent directory, as editors and build tools often replace a file rather than writing to it
+		err = watcher.Add(filepath.Dir(path))
+	}
+	if err != nil {
+		return
+	}
+	log.WithField("path", path).Info("Watching for changes")
+	if opts.OnReady != nil {
+		opts.OnReady()
+	}
+
+	var settled <-chan time.Time
+	for {
+		select {
+		case <-stop:
+			return nil
+		case event, ok := <-watcher.Events:
+			if !ok {
+				return nil
+			}
+			if event.Op == fsnotify.Chmod {
+				continue
+			}
+			if !isDir && filepath.Clean(event.Name) != filepath.Clean(path) {
+				continue
+			}
+			if excluded(event.Name) {
+				log.WithField("event", event.String()).Trace("Ignoring change to excluded path")
+				continue
+			}
+			if isDir && event.Op&fsnotify.Create != 0 {
+				if created, err := os.Stat(event.Name); err == nil && created.IsDir() {
+					if err = addTree(watcher, event.Name, excluded); err != nil {
+						log.WithField("error", err).Warn("Unable to watch new directory")
+					}
+				}
+			}
+			log.WithField("event", event.String()).Debug("Noticed change")
+			settled = time.After(opts.Debounce)
+		case err, ok := <-watcher.Errors:
+			if !ok {
+				return nil
+			}
+			log.WithField("error", err).Warn("Error while watching for changes")
+		case <-settled:
+			settled = nil
+			log.WithField("path", path).Info("Changes have settled")
+			onChange()
+		}
+	}
+}
+
+func addTree(watcher *fsnotify.Watcher, root string, excluded func(string) bool) error {
+	return filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if !info.IsDir() {
+			return nil
+		}
+		if excluded(path) {
+			return filepath.SkipDir
+		}
+		return watcher.Add(path)
+	})
+}
+
+// excluder returns a func that reports whether a path is one of exclude, or inside of one of them
+func excluder(exclude []string) func(string) bool {
+	var roots []string
+	for _, v := range exclude {
+		if abs, err := filepath.Abs(v); err == nil {
+			roots = append(roots, abs)
+		}
+	}
+
+	return func(path string) bool {
+		abs, err := filepath.Abs(path)
+		if err != nil {
+			return false
+		}
+		for _, root := range roots {
+			if abs == root || strings.HasPrefix(abs, root+string(filepath.Separator)) {
+				return true
+			}
+		}
+		return false
+	}
+}
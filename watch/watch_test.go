@@ -0,0 +1,127 @@
+//
+// Copyright © 2020-present Sonatype Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+package watch
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const debounce = 200 * time.Millisecond
+
+// watchInBackground starts watching path, and returns a channel that gets a value every time onChange is called once
+// the watcher is ready for changes
+func watchInBackground(t *testing.T, path string, exclude ...string) <-chan struct{} {
+	stop := make(chan struct{})
+	ready := make(chan struct{})
+	changes := make(chan struct{}, 10)
+	done := make(chan error, 1)
+	opts := Options{Debounce: debounce, Exclude: exclude, OnReady: func() { close(ready) }}
+	go func() {
+		done <- Path(path, opts, stop, func() { changes <- struct{}{} })
+	}()
+	t.Cleanup(func() {
+		close(stop)
+		assert.NoError(t, <-done)
+	})
+
+	select {
+	case <-ready:
+	case err := <-done:
+		t.Fatalf("Watcher stopped before it was ready: %v", err)
+	}
+	return changes
+}
+
+func countChanges(changes <-chan struct{}) (count int) {
+	timeout := time.After(4 * debounce)
+	for {
+		select {
+		case <-changes:
+			count++
+		case <-timeout:
+			return
+		}
+	}
+}
+
+func tempDir(t *testing.T) string {
+	dir, err := ioutil.TempDir("", "watch")
+	assert.NoError(t, err)
+	t.Cleanup(func() { os.RemoveAll(dir) })
+	return dir
+}
+
+func TestPathDirectoryDebounces(t *testing.T) {
+	dir := tempDir(t)
+	changes := watchInBackground(t, dir)
+
+	for _, name := range []string{"one.jar", "two.jar", "three.jar"} {
+		assert.NoError(t, ioutil.WriteFile(filepath.Join(dir, name), []byte(name), 0644))
+	}
+
+	assert.Equal(t, 1, countChanges(changes))
+}
+
+func TestPathDirectoryWatchesNewSubdirectories(t *testing.T) {
+	dir := tempDir(t)
+	changes := watchInBackground(t, dir)
+
+	sub := filepath.Join(dir, "lib")
+	assert.NoError(t, os.Mkdir(sub, 0755))
+	assert.Equal(t, 1, countChanges(changes))
+
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(sub, "foo.jar"), []byte("foo"), 0644))
+	assert.Equal(t, 1, countChanges(changes))
+}
+
+func TestPathFileIgnoresSiblings(t *testing.T) {
+	dir := tempDir(t)
+	path := filepath.Join(dir, "sha1s.txt")
+	assert.NoError(t, ioutil.WriteFile(path, nil, 0644))
+	changes := watchInBackground(t, path)
+
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(dir, "other.txt"), []byte("other"), 0644))
+	assert.Equal(t, 0, countChanges(changes))
+
+	assert.NoError(t, ioutil.WriteFile(path, []byte("changed"), 0644))
+	assert.Equal(t, 1, countChanges(changes))
+}
+
+func TestPathDirectoryIgnoresExcluded(t *testing.T) {
+	dir := tempDir(t)
+	cache := filepath.Join(dir, ".cache")
+	assert.NoError(t, os.Mkdir(cache, 0755))
+	changes := watchInBackground(t, dir, cache, filepath.Join(dir, "hashbrowns.log"))
+
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(cache, "hashes.json"), []byte("{}"), 0644))
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(dir, "hashbrowns.log"), []byte("audited"), 0644))
+	assert.Equal(t, 0, countChanges(changes))
+
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(dir, "foo.jar"), []byte("foo"), 0644))
+	assert.Equal(t, 1, countChanges(changes))
+}
+
+func TestPathMissing(t *testing.T) {
+	err := Path(filepath.Join(tempDir(t), "missing"), Options{}, nil, func() {})
+
+	assert.True(t, os.IsNotExist(err))
+}